@@ -0,0 +1,223 @@
+// Package api exposes the kitchen over HTTP, so orders can be submitted to a running kitchen and its shelves can be inspected
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"delivery/kitchen"
+)
+
+// Server handles HTTP requests to the kitchen
+type Server struct {
+	kitchen *kitchen.Kitchen
+	mux     *http.ServeMux
+}
+
+// OrderResponse is the order with its current state
+type OrderResponse struct {
+	kitchen.Order
+	// name of the shelf the order is on
+	Shelf string `json:"shelf"`
+	// current inherent value of the order
+	Value float64 `json:"value"`
+}
+
+// ShelfResponse is the shelf with the orders on it
+type ShelfResponse struct {
+	Name        string          `json:"name"`
+	Temperature string          `json:"temp"`
+	Capacity    int             `json:"cap"`
+	Orders      []OrderResponse `json:"orders"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewServer creates new HTTP server for the kitchen
+func NewServer(k *kitchen.Kitchen) *Server {
+	s := &Server{
+		kitchen: k,
+		mux:     http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/orders", s.handleOrders)
+	s.mux.HandleFunc("/orders/", s.handleOrder)
+	s.mux.HandleFunc("/shelves", s.handleShelves)
+	s.mux.HandleFunc("/pause", s.handlePause)
+	s.mux.HandleFunc("/resume", s.handleResume)
+
+	return s
+}
+
+// ServeHTTP dispatches the request to the handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleOrders places the order from the request body on the kitchen and creates a courier for it
+func (s *Server) handleOrders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	order := &kitchen.Order{}
+	if err := json.NewDecoder(r.Body).Decode(order); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid order: "+err.Error())
+		return
+	}
+
+	if order.ID == "" || order.Temperature == "" || order.ShelfLife <= 0 {
+		writeError(w, http.StatusBadRequest, "order must have 'id', 'temp' and positive 'shelfLife'")
+		return
+	}
+
+	if _, err := kitchen.NewDecayModel(order.Decay); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid order: "+err.Error())
+		return
+	}
+
+	// the orders of the files and streams wait while the kitchen is paused, the client retries later
+	if s.kitchen.IsOnPause() {
+		writeError(w, http.StatusServiceUnavailable, "kitchen is paused")
+		return
+	}
+
+	if err := s.kitchen.Place(order); err != nil {
+		status := http.StatusConflict
+		if err == kitchen.ErrClosed {
+			status = http.StatusServiceUnavailable
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+
+	s.kitchen.CreateCourier(order)
+
+	placed, shelf, ok := s.kitchen.FindOrder(order.ID)
+	if !ok {
+		writeJSON(w, http.StatusCreated, OrderResponse{Order: *order})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newOrderResponse(placed, shelf, s.kitchen.Now()))
+}
+
+// handleOrder returns the status of the order by the ID from the path or cancels the order
+func (s *Server) handleOrder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	orderID := strings.TrimPrefix(r.URL.Path, "/orders/")
+	if orderID == "" || strings.Contains(orderID, "/") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		s.handleCancel(w, orderID)
+		return
+	}
+
+	status, ok := s.kitchen.OrderStatus(orderID)
+	if !ok {
+		writeError(w, http.StatusNotFound, "order not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleCancel cancels the order that is still on a shelf
+func (s *Server) handleCancel(w http.ResponseWriter, orderID string) {
+	if _, ok := s.kitchen.OrderStatus(orderID); !ok {
+		writeError(w, http.StatusNotFound, "order not found")
+		return
+	}
+
+	if !s.kitchen.CancelOrder(orderID) {
+		writeError(w, http.StatusConflict, "order has already left the kitchen")
+		return
+	}
+
+	status, _ := s.kitchen.OrderStatus(orderID)
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleShelves returns the shelves with their orders
+func (s *Server) handleShelves(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	shelves := s.kitchen.AllShelves()
+	now := s.kitchen.Now()
+
+	result := make([]ShelfResponse, 0, len(shelves))
+	for _, shelf := range shelves {
+		orders := shelf.Orders()
+
+		response := ShelfResponse{
+			Name:        shelf.Name,
+			Temperature: shelf.Temperature,
+			Capacity:    shelf.Capacity,
+			Orders:      make([]OrderResponse, 0, len(orders)),
+		}
+		for _, order := range orders {
+			response.Orders = append(response.Orders, newOrderResponse(order, shelf, now))
+		}
+
+		result = append(result, response)
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handlePause pauses the kitchen
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	s.kitchen.Pause()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleResume unpause the kitchen
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	s.kitchen.Unpause()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newOrderResponse(order kitchen.Order, shelf *kitchen.Shelf, now time.Time) OrderResponse {
+	return OrderResponse{
+		Order: order,
+		Shelf: shelf.Name,
+		Value: order.GetInherentValue(now),
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
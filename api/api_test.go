@@ -0,0 +1,279 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	c "delivery/config"
+	"delivery/kitchen"
+)
+
+func init() {
+	c.Init("../config.yml")
+}
+
+func newTestServer() (*httptest.Server, *kitchen.Kitchen) {
+	clock := kitchen.NewVirtualClock(time.Now())
+
+	k := kitchen.New(
+		clock,
+		map[string]*kitchen.Shelf{
+			"hot": kitchen.NewShelf(clock, "Hot shelf", "hot", 1, 1),
+		},
+		[]*kitchen.Shelf{kitchen.NewShelf(clock, "Overflow shelf", "any", 1, 2)},
+	)
+
+	return httptest.NewServer(NewServer(k)), k
+}
+
+func postOrder(t *testing.T, url string, order kitchen.Order) *http.Response {
+	body, err := json.Marshal(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Post(url+"/orders", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp
+}
+
+func TestServer(t *testing.T) {
+	t.Run("PostOrder", func(t *testing.T) {
+		server, _ := newTestServer()
+		defer server.Close()
+
+		resp := postOrder(t, server.URL, kitchen.Order{ID: "1", Name: "Pizza", Temperature: "hot", ShelfLife: 300, DecayRate: 0.5})
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("got %v want %v", resp.StatusCode, http.StatusCreated)
+		}
+
+		var got OrderResponse
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+
+		if got.ID != "1" || got.Shelf != "Hot shelf" {
+			t.Errorf("got %+v want %v", got, "order on the hot shelf")
+		}
+	})
+
+	t.Run("PostOrder_Negative", func(t *testing.T) {
+		server, _ := newTestServer()
+		defer server.Close()
+
+		cases := []struct {
+			order kitchen.Order
+			want  int
+		}{
+			{kitchen.Order{ID: "1", Temperature: "hot"}, http.StatusBadRequest},
+			{kitchen.Order{ID: "2", Temperature: "cold", ShelfLife: 300}, http.StatusConflict},
+			{kitchen.Order{ID: "3", Temperature: "hot", ShelfLife: 300}, http.StatusCreated},
+			{kitchen.Order{ID: "3", Temperature: "hot", ShelfLife: 300}, http.StatusConflict},
+		}
+
+		for _, tc := range cases {
+			resp := postOrder(t, server.URL, tc.order)
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.want {
+				t.Errorf("got %v want %v", resp.StatusCode, tc.want)
+			}
+		}
+	})
+
+	t.Run("PostOrder_Concurrent", func(t *testing.T) {
+		server, k := newTestServer()
+		defer server.Close()
+
+		body, err := json.Marshal(kitchen.Order{ID: "1", Temperature: "hot", ShelfLife: 300, DecayRate: 0.5})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		statuses := make(chan int)
+		for i := 0; i < 10; i++ {
+			go func() {
+				resp, err := http.Post(server.URL+"/orders", "application/json", bytes.NewReader(body))
+				if err != nil {
+					statuses <- 0
+					return
+				}
+				resp.Body.Close()
+				statuses <- resp.StatusCode
+			}()
+		}
+
+		created := 0
+		for i := 0; i < 10; i++ {
+			switch status := <-statuses; status {
+			case http.StatusCreated:
+				created++
+			case http.StatusConflict:
+			default:
+				t.Errorf("got %v want %v", status, http.StatusConflict)
+			}
+		}
+
+		if created != 1 || k.Stats().Received != 1 {
+			t.Errorf("got %v want %v", created, 1)
+		}
+	})
+
+	t.Run("PostOrder_Unavailable", func(t *testing.T) {
+		server, k := newTestServer()
+		defer server.Close()
+
+		k.Pause()
+		resp := postOrder(t, server.URL, kitchen.Order{ID: "1", Temperature: "hot", ShelfLife: 300})
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("got %v want %v", resp.StatusCode, http.StatusServiceUnavailable)
+		}
+
+		k.Unpause()
+		k.Close()
+		resp = postOrder(t, server.URL, kitchen.Order{ID: "2", Temperature: "hot", ShelfLife: 300})
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("got %v want %v", resp.StatusCode, http.StatusServiceUnavailable)
+		}
+	})
+
+	t.Run("GetOrder", func(t *testing.T) {
+		server, _ := newTestServer()
+		defer server.Close()
+
+		postOrder(t, server.URL, kitchen.Order{ID: "1", Temperature: "hot", ShelfLife: 300, DecayRate: 0.5}).Body.Close()
+		postOrder(t, server.URL, kitchen.Order{ID: "2", Temperature: "hot", ShelfLife: 300, DecayRate: 0.5}).Body.Close()
+
+		resp, err := http.Get(server.URL + "/orders/2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		var got kitchen.OrderStatus
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+
+		if got.State != kitchen.StateOnShelf || got.Shelf != "Overflow shelf" || got.Value != 1 || got.CourierETA == nil {
+			t.Errorf("got %+v want %v", got, "order on the overflow shelf")
+		}
+
+		resp, err = http.Get(server.URL + "/orders/3")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("got %v want %v", resp.StatusCode, http.StatusNotFound)
+		}
+	})
+
+	t.Run("CancelOrder", func(t *testing.T) {
+		server, k := newTestServer()
+		defer server.Close()
+
+		postOrder(t, server.URL, kitchen.Order{ID: "1", Temperature: "hot", ShelfLife: 300, DecayRate: 0.5}).Body.Close()
+
+		cases := []struct {
+			path string
+			want int
+		}{
+			{"/orders/1", http.StatusOK},
+			{"/orders/1", http.StatusConflict},
+			{"/orders/2", http.StatusNotFound},
+		}
+
+		for _, tc := range cases {
+			req, err := http.NewRequest(http.MethodDelete, server.URL+tc.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.want {
+				t.Errorf("got %v want %v", resp.StatusCode, tc.want)
+			}
+		}
+
+		if k.Stats().Cancelled != 1 {
+			t.Errorf("got %v want %v", k.Stats().Cancelled, 1)
+		}
+	})
+
+	t.Run("GetShelves", func(t *testing.T) {
+		server, _ := newTestServer()
+		defer server.Close()
+
+		postOrder(t, server.URL, kitchen.Order{ID: "1", Temperature: "hot", ShelfLife: 300, DecayRate: 0.5}).Body.Close()
+
+		resp, err := http.Get(server.URL + "/shelves")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		var got []ShelfResponse
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) != 2 || len(got[0].Orders) != 1 || len(got[1].Orders) != 0 {
+			t.Errorf("got %+v want %v", got, "one order on the hot shelf")
+		}
+	})
+
+	t.Run("PauseResume", func(t *testing.T) {
+		server, k := newTestServer()
+		defer server.Close()
+
+		resp, err := http.Post(server.URL+"/pause", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if !k.IsOnPause() {
+			t.Errorf("got %v want %v", k.IsOnPause(), true)
+		}
+
+		resp, err = http.Post(server.URL+"/resume", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if k.IsOnPause() {
+			t.Errorf("got %v want %v", k.IsOnPause(), false)
+		}
+
+		resp, err = http.Get(server.URL + "/pause")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("got %v want %v", resp.StatusCode, http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	c "delivery/config"
+)
+
+// configCommand runs the config subcommands, 'check' only validates the config file
+func configCommand(args []string) error {
+	if len(args) == 0 || args[0] != "check" {
+		return fmt.Errorf("usage: config check [-c config.yml]")
+	}
+
+	flags := flag.NewFlagSet("config check", flag.ExitOnError)
+	configPath := flags.String("c", "config.yml", "Config file path")
+	flags.Parse(args[1:])
+
+	return checkConfig(os.Stdout, *configPath)
+}
+
+// checkConfig writes all problems of the config file to w as 'path:line: problem'
+func checkConfig(w io.Writer, path string) error {
+	_, err := c.Load(path)
+	problems, ok := err.(c.Errors)
+	if err != nil && !ok {
+		return err
+	}
+
+	if len(problems) == 0 {
+		fmt.Fprintf(w, "%s is valid\n", path)
+		return nil
+	}
+
+	writeProblems(w, path, problems)
+
+	return fmt.Errorf("%s has %d problems", path, len(problems))
+}
+
+// writeProblems writes the problems of the config file as 'path:line: problem'
+func writeProblems(w io.Writer, path string, problems c.Errors) {
+	for _, problem := range problems {
+		if problem.Line > 0 {
+			fmt.Fprintf(w, "%s:%d: %s\n", path, problem.Line, problem.Message)
+		} else {
+			fmt.Fprintf(w, "%s: %s\n", path, problem.Message)
+		}
+	}
+}
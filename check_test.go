@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckConfig(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := checkConfig(&out, "config.yml"); err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := out.String(), "config.yml is valid\n"; got != want {
+			t.Errorf("got %q want %q", got, want)
+		}
+	})
+
+	t.Run("Invalid_Negative", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "delivery")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		contents, err := ioutil.ReadFile("config.yml")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		path := filepath.Join(dir, "config.yml")
+		contents = bytes.Replace(contents, []byte("count: 2"), []byte("count: 0"), 1)
+		if err := ioutil.WriteFile(path, contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var out bytes.Buffer
+		if err := checkConfig(&out, path); err == nil {
+			t.Errorf("got %v want %v", err, "error")
+		}
+
+		if want := path + ":4: 'order.ingestionRate.count' must be positive"; !strings.Contains(out.String(), want) {
+			t.Errorf("got %q want %q", out.String(), want)
+		}
+	})
+}
@@ -2,15 +2,20 @@
 package config
 
 import (
-	"errors"
+	"fmt"
 	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
 
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
 // DeliveryConfig -
 type DeliveryConfig struct {
+	// seed of the random generator, a random seed is used if it is not set
+	Seed  *int64 `yaml:"seed"`
 	Order struct {
 		IngestionRate struct {
 			Count    int           `yaml:"count"`
@@ -26,14 +31,30 @@ type DeliveryConfig struct {
 		Name          string `yaml:"name"`
 		Temperature   string `yaml:"temp"`
 		Capacity      int    `yaml:"cap"`
-		DecayModifier int    `yaml:"decayModifier​"`
+		DecayModifier int    `yaml:"decayModifier"`
 	} `yaml:"shelves"`
 	OverflowShelf struct {
 		Name          string `yaml:"name"`
 		Temperature   string `yaml:"temp"`
 		Capacity      int    `yaml:"cap"`
-		DecayModifier int    `yaml:"decayModifier​"`
+		DecayModifier int    `yaml:"decayModifier"`
+		DiscardPolicy string `yaml:"discardPolicy"`
 	} `yaml:"overflowShelf"`
+	OverflowShelves []struct {
+		Name          string   `yaml:"name"`
+		Temperatures  []string `yaml:"temps"`
+		Capacity      int      `yaml:"cap"`
+		DecayModifier int      `yaml:"decayModifier"`
+		DiscardPolicy string   `yaml:"discardPolicy"`
+	} `yaml:"overflowShelves"`
+	Compatibility []struct {
+		Temperature string `yaml:"temp"`
+		Shelves     []struct {
+			Temperature     string  `yaml:"temp"`
+			Name            string  `yaml:"name"`
+			DecayMultiplier float64 `yaml:"decayMultiplier"`
+		} `yaml:"shelves"`
+	} `yaml:"compatibility"`
 	Courier struct {
 		Arrive struct {
 			Time     string        `yaml:"time"`
@@ -41,50 +62,394 @@ type DeliveryConfig struct {
 			Min      int           `yaml:"min"`
 			Max      int           `yaml:"max"`
 		} `yaml:"arrive"`
+		Dispatch string `yaml:"dispatch"`
+		Fleet    struct {
+			Size     int `yaml:"size"`
+			Capacity int `yaml:"capacity"`
+			Shifts   []struct {
+				Start         string        `yaml:"start"`
+				StartDuration time.Duration `yaml:"-"`
+				End           string        `yaml:"end"`
+				EndDuration   time.Duration `yaml:"-"`
+				Size          int           `yaml:"size"`
+			} `yaml:"shifts"`
+		} `yaml:"fleet"`
 	} `yaml:"courier"`
 }
 
 // Config delivery config
 var Config *DeliveryConfig
 
+// DispatchStrategies are the names of the courier dispatch strategies of the kitchen, an empty name chooses the first one
+var DispatchStrategies = []string{"matched", "fifo"}
+
+// DiscardPolicies are the names of the discard policies of the overflow shelves, an empty name chooses the first one
+var DiscardPolicies = []string{"random", "lowestValue", "soonestToExpire", "oldest", "highestDecayRate"}
+
 // Init initialize configuration
 func Init(path string) error {
-	Config = &DeliveryConfig{}
-
-	contents, err := ioutil.ReadFile(path)
+	config, err := Load(path)
 	if err != nil {
 		return err
 	}
 
-	err = yaml.Unmarshal(contents, Config)
+	Config = config
+
+	return nil
+}
+
+// Load reads the configuration from the file without changing the global one.
+// The config is validated, all problems found are returned together as Errors
+func Load(path string) (*DeliveryConfig, error) {
+	contents, err := ioutil.ReadFile(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	duration, err := time.ParseDuration(Config.Order.IngestionRate.Time)
-	if err != nil {
-		return err
+	return Parse(contents)
+}
+
+// Parse decodes and validates the configuration, all problems found are returned together as Errors
+func Parse(contents []byte) (*DeliveryConfig, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return nil, err
 	}
 
-	Config.Order.IngestionRate.Duration = duration
+	config := &DeliveryConfig{}
+	v := &validator{lines: make(map[string]int)}
 
-	duration, err = time.ParseDuration(Config.Order.Age.Time)
-	if err != nil {
-		return err
+	if len(document.Content) > 0 {
+		root := document.Content[0]
+		v.checkKeys(root, reflect.TypeOf(config).Elem(), "")
+
+		if err := root.Decode(config); err != nil {
+			typeErr, ok := err.(*yaml.TypeError)
+			if !ok {
+				return nil, err
+			}
+
+			for _, message := range typeErr.Errors {
+				v.errors = append(v.errors, typeError(message))
+			}
+		}
+	}
+
+	// the value that cannot be decoded is zero, so only the decoding problem is reported for its line
+	undecoded := make(map[int]bool, len(v.errors))
+	for _, err := range v.errors {
+		undecoded[err.Line] = err.Line > 0
 	}
 
-	Config.Order.Age.Duration = duration
+	decoded := len(v.errors)
+	config.validate(v)
 
-	if Config.Courier.Arrive.Min > Config.Courier.Arrive.Max {
-		return errors.New("'Courier.Arrive.Min' cannot be less then 'Courier.Arrive.Max'")
+	errs := append(Errors(nil), v.errors[:decoded]...)
+	for _, err := range v.errors[decoded:] {
+		if !undecoded[err.Line] {
+			errs = append(errs, err)
+		}
 	}
 
-	duration, err = time.ParseDuration(Config.Courier.Arrive.Time)
-	if err != nil {
-		return err
+	if len(errs) > 0 {
+		// the problems without a line go last
+		sort.SliceStable(errs, func(i, j int) bool {
+			return errs[i].Line != 0 && (errs[j].Line == 0 || errs[i].Line < errs[j].Line)
+		})
+		return nil, errs
+	}
+
+	return config, nil
+}
+
+// typeError returns the decoding problem, the message of the YAML decoder starts with the line
+func typeError(message string) Error {
+	var line int
+	if _, err := fmt.Sscanf(message, "line %d:", &line); err != nil {
+		return Error{Message: message}
 	}
 
-	Config.Courier.Arrive.Duration = duration
+	return Error{Line: line, Message: strings.TrimSpace(message[strings.Index(message, ":")+1:])}
+}
+
+// Validate checks the values of the config and parses its durations, all problems found are returned together as Errors
+func (config *DeliveryConfig) Validate() error {
+	v := &validator{}
+	config.validate(v)
+
+	if len(v.errors) > 0 {
+		return v.errors
+	}
 
 	return nil
 }
+
+// validate checks the values of the config and parses its durations
+func (config *DeliveryConfig) validate(v *validator) {
+	ingestionRate := &config.Order.IngestionRate
+	if ingestionRate.Count <= 0 {
+		v.errorf("order.ingestionRate.count", "'order.ingestionRate.count' must be positive")
+	}
+	ingestionRate.Duration = v.duration("order.ingestionRate.time", ingestionRate.Time)
+	config.Order.Age.Duration = v.duration("order.age.time", config.Order.Age.Time)
+
+	arrive := &config.Courier.Arrive
+	arrive.Duration = v.duration("courier.arrive.time", arrive.Time)
+	if arrive.Min < 0 {
+		v.errorf("courier.arrive.min", "'courier.arrive.min' cannot be negative")
+	}
+	if arrive.Min > arrive.Max {
+		v.errorf("courier.arrive.min", "'courier.arrive.min' cannot be greater than 'courier.arrive.max'")
+	}
+	v.oneOf("courier.dispatch", config.Courier.Dispatch, DispatchStrategies)
+
+	temps := make(map[string]string, len(config.Shelves))
+	// the state of the kitchen refers to the shelves by their names
+	names := make(map[string]string, len(config.Shelves)+len(config.OverflowShelves)+1)
+	for i, shelf := range config.Shelves {
+		path := fmt.Sprintf("shelves[%d]", i)
+		v.name(names, path, shelf.Name)
+		switch used, ok := temps[shelf.Temperature]; {
+		case shelf.Temperature == "":
+			v.errorf(path+".temp", "'%s.temp' is required", path)
+		case ok:
+			v.errorf(path+".temp", "'%s.temp' must be unique, '%s' is already used by '%s'", path, shelf.Temperature, used)
+		default:
+			temps[shelf.Temperature] = path
+		}
+		v.shelf(path, shelf.Capacity, shelf.DecayModifier)
+	}
+
+	overflowNames := make(map[string]bool, len(config.OverflowShelves)+1)
+	overflowShelf := config.OverflowShelf
+	hasOverflowShelf := overflowShelf.Name != "" || overflowShelf.Temperature != "" || overflowShelf.Capacity != 0 ||
+		overflowShelf.DecayModifier != 0 || overflowShelf.DiscardPolicy != ""
+	switch {
+	case hasOverflowShelf && len(config.OverflowShelves) > 0:
+		v.errorf("overflowShelf", "'overflowShelf' cannot be given together with 'overflowShelves'")
+	case hasOverflowShelf:
+		v.name(names, "overflowShelf", overflowShelf.Name)
+		v.shelf("overflowShelf", overflowShelf.Capacity, overflowShelf.DecayModifier)
+		v.oneOf("overflowShelf.discardPolicy", overflowShelf.DiscardPolicy, DiscardPolicies)
+		overflowNames[overflowShelf.Name] = true
+	case len(config.OverflowShelves) == 0:
+		v.errorf("overflowShelf", "'overflowShelf' is required unless 'overflowShelves' are given")
+	}
+
+	// orders of a temperature without a shelf are placed only when the compatibility lists shelves for them
+	orderTemps := make(map[string]bool, len(temps)+len(config.Compatibility))
+	for temp := range temps {
+		orderTemps[temp] = true
+	}
+	for _, compatibility := range config.Compatibility {
+		orderTemps[compatibility.Temperature] = true
+	}
+
+	for i, shelf := range config.OverflowShelves {
+		path := fmt.Sprintf("overflowShelves[%d]", i)
+		v.name(names, path, shelf.Name)
+		v.shelf(path, shelf.Capacity, shelf.DecayModifier)
+		v.oneOf(path+".discardPolicy", shelf.DiscardPolicy, DiscardPolicies)
+		overflowNames[shelf.Name] = true
+
+		for j, temp := range shelf.Temperatures {
+			if !orderTemps[temp] {
+				tempPath := fmt.Sprintf("%s.temps[%d]", path, j)
+				v.errorf(tempPath, "'%s' is not the temperature of a shelf or a compatibility entry: %s", tempPath, temp)
+			}
+		}
+	}
+
+	for i, compatibility := range config.Compatibility {
+		for j, shelf := range compatibility.Shelves {
+			path := fmt.Sprintf("compatibility[%d].shelves[%d]", i, j)
+			switch _, ok := temps[shelf.Temperature]; {
+			case shelf.Temperature == "" && shelf.Name == "":
+				v.errorf(path, "'%s' requires 'temp' or 'name'", path)
+			case shelf.Temperature != "" && shelf.Name != "":
+				v.errorf(path+".name", "'%s' cannot have both 'temp' and 'name'", path)
+			case shelf.Temperature != "" && !ok:
+				v.errorf(path+".temp", "'%s.temp' is not the temperature of a shelf: %s", path, shelf.Temperature)
+			case shelf.Name != "" && !overflowNames[shelf.Name]:
+				v.errorf(path+".name", "'%s.name' is not the name of an overflow shelf: %s", path, shelf.Name)
+			}
+
+			if shelf.DecayMultiplier <= 0 {
+				v.errorf(path+".decayMultiplier", "'%s.decayMultiplier' must be positive", path)
+			}
+		}
+	}
+
+	fleet := &config.Courier.Fleet
+	if fleet.Size < 0 {
+		v.errorf("courier.fleet.size", "'courier.fleet.size' cannot be negative")
+	}
+	if fleet.Capacity < 0 {
+		v.errorf("courier.fleet.capacity", "'courier.fleet.capacity' cannot be negative")
+	}
+
+	for i := range fleet.Shifts {
+		shift := &fleet.Shifts[i]
+		path := fmt.Sprintf("courier.fleet.shifts[%d]", i)
+
+		var err error
+		if shift.StartDuration, err = time.ParseDuration(shift.Start); err != nil {
+			v.errorf(path+".start", "'%s.start' is not a duration: %s", path, shift.Start)
+		}
+		if shift.EndDuration, err = time.ParseDuration(shift.End); err != nil {
+			v.errorf(path+".end", "'%s.end' is not a duration: %s", path, shift.End)
+		} else if shift.EndDuration <= shift.StartDuration {
+			v.errorf(path+".end", "'%s.end' must be after '%s.start'", path, path)
+		}
+	}
+}
+
+// Error is a problem of the config at the line of the file
+type Error struct {
+	// line of the file, zero if the line is unknown
+	Line    int
+	Message string
+}
+
+// Error -
+func (e Error) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Errors are all problems found in the config, ordered by line
+type Errors []Error
+
+// Error -
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return "invalid config:\n" + strings.Join(messages, "\n")
+}
+
+// validator collects the problems of the config
+type validator struct {
+	// lines of the keys by their paths, e.g. 'shelves[0].cap'
+	lines  map[string]int
+	errors Errors
+}
+
+// errorf adds the problem of the value at the path, the line of the closest key present in the file is reported
+func (v *validator) errorf(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, Error{Line: v.line(path), Message: fmt.Sprintf(format, args...)})
+}
+
+// line returns the line of the key at the path or of its closest parent
+func (v *validator) line(path string) int {
+	for path != "" {
+		if line, ok := v.lines[path]; ok {
+			return line
+		}
+
+		path = path[:strings.LastIndexAny(path, ".[")+1]
+		path = strings.TrimRight(path, ".[")
+	}
+
+	return 0
+}
+
+// duration parses the positive duration at the path
+func (v *validator) duration(path, value string) time.Duration {
+	duration, err := time.ParseDuration(value)
+	switch {
+	case value == "":
+		v.errorf(path, "'%s' is required", path)
+	case err != nil:
+		v.errorf(path, "'%s' is not a duration: %s", path, value)
+	case duration <= 0:
+		v.errorf(path, "'%s' must be positive", path)
+	}
+
+	return duration
+}
+
+// shelf checks the capacity and the decay modifier of the shelf at the path
+func (v *validator) shelf(path string, capacity, decayModifier int) {
+	if capacity <= 0 {
+		v.errorf(path+".cap", "'%s.cap' must be positive", path)
+	}
+
+	if decayModifier < 0 {
+		v.errorf(path+".decayModifier", "'%s.decayModifier' cannot be negative", path)
+	}
+}
+
+// name checks that the name of the shelf at the path is not used by another shelf
+func (v *validator) name(names map[string]string, path, name string) {
+	if used, ok := names[name]; ok {
+		v.errorf(path+".name", "'%s.name' must be unique, '%s' is already used by '%s'", path, name, used)
+		return
+	}
+
+	names[name] = path
+}
+
+// oneOf checks that the value at the path is one of the names, an empty value chooses the default
+func (v *validator) oneOf(path, value string, names []string) {
+	if value == "" {
+		return
+	}
+
+	for _, name := range names {
+		if value == name {
+			return
+		}
+	}
+
+	v.errorf(path, "'%s' must be one of %s: %s", path, strings.Join(names, ", "), value)
+}
+
+// checkKeys records the lines of the keys and reports the keys that are not fields of the type
+func (v *validator) checkKeys(node *yaml.Node, t reflect.Type, path string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := make(map[string]reflect.Type, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+			if name != "" && name != "-" {
+				fields[name] = t.Field(i).Type
+			}
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			// the decayModifier keys of the older configs end with a zero-width space, such configs keep working
+			key.Value = strings.Replace(key.Value, "\u200b", "", -1)
+
+			keyPath := key.Value
+			if path != "" {
+				keyPath = path + "." + key.Value
+			}
+			v.lines[keyPath] = key.Line
+
+			field, ok := fields[key.Value]
+			if !ok {
+				v.errors = append(v.errors, Error{Line: key.Line, Message: fmt.Sprintf("unknown key '%s'", keyPath)})
+				continue
+			}
+
+			v.checkKeys(value, field, keyPath)
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			v.lines[itemPath] = item.Line
+			v.checkKeys(item, t.Elem(), itemPath)
+		}
+	}
+}
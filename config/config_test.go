@@ -0,0 +1,203 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+const valid = `
+order:
+  ingestionRate:
+    count: 2
+    time: 1s
+  age:
+    time: 1s
+courier:
+  arrive:
+    time: 1s
+    min: 2
+    max: 6
+shelves:
+  - name: Hot shelf
+    temp: hot
+    cap: 10
+    decayModifier: 1
+overflowShelf:
+  name: Overflow shelf
+  temp: any
+  cap: 15
+  decayModifier: 2
+`
+
+func TestParse(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		config, err := Parse([]byte(valid))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if config.Order.IngestionRate.Duration != time.Second || config.Shelves[0].DecayModifier != 1 {
+			t.Errorf("got %+v want %v", config, "parsed durations and decay modifier")
+		}
+	})
+
+	t.Run("ZeroWidthSpace", func(t *testing.T) {
+		config, err := Parse([]byte("order:\n  ingestionRate: {count: 1, time: 1s}\n  age: {time: 1s}\n" +
+			"courier:\n  arrive: {time: 1s, min: 1, max: 1}\n" +
+			"overflowShelf:\n  cap: 1\n  decayModifier\u200b: 2\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := config.OverflowShelf.DecayModifier, 2; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+	})
+
+	t.Run("Invalid_Negative", func(t *testing.T) {
+		_, err := Parse([]byte(`
+order:
+  ingestionRate:
+    count: 0
+    time: 1s
+  age:
+    time: soon
+courier:
+  arrive:
+    time: 1s
+    min: 6
+    max: 2
+  colour: red
+shelves:
+  - name: Hot shelf
+    temp: hot
+    cap: 0
+    decayModifier: -1
+  - name: Warm shelf
+    temp: hot
+    cap: ten
+`))
+
+		errs, ok := err.(Errors)
+		if !ok {
+			t.Fatalf("got %v want %v", err, "Errors")
+		}
+
+		want := Errors{
+			{Line: 4, Message: "'order.ingestionRate.count' must be positive"},
+			{Line: 7, Message: "'order.age.time' is not a duration: soon"},
+			{Line: 11, Message: "'courier.arrive.min' cannot be greater than 'courier.arrive.max'"},
+			{Line: 13, Message: "unknown key 'courier.colour'"},
+			{Line: 17, Message: "'shelves[0].cap' must be positive"},
+			{Line: 18, Message: "'shelves[0].decayModifier' cannot be negative"},
+			{Line: 20, Message: "'shelves[1].temp' must be unique, 'hot' is already used by 'shelves[0]'"},
+			{Line: 21, Message: "cannot unmarshal !!str `ten` into int"},
+			{Message: "'overflowShelf' is required unless 'overflowShelves' are given"},
+		}
+
+		if len(errs) != len(want) {
+			t.Fatalf("got %v want %v", errs, want)
+		}
+
+		for i := range want {
+			if errs[i] != want[i] {
+				t.Errorf("got %v want %v", errs[i], want[i])
+			}
+		}
+	})
+
+	t.Run("Invalid_Names", func(t *testing.T) {
+		_, err := Parse([]byte(`
+order:
+  ingestionRate: {count: 1, time: 1s}
+  age: {time: 1s}
+courier:
+  arrive: {time: 1s, min: 1, max: 1}
+  dispatch: lifo
+shelves:
+  - name: Hot shelf
+    temp: hot
+    cap: 1
+overflowShelves:
+  - name: Hot shelf
+    cap: 1
+    discardPolicy: newest
+  - name: Overflow shelf
+    cap: 1
+  - name: Overflow shelf
+    cap: 1
+compatibility:
+  - temp: frozen
+    shelves:
+      - temp: cold
+        decayMultiplier: 3
+      - name: Cold overflow shelf
+        decayMultiplier: 2
+`))
+
+		errs, ok := err.(Errors)
+		if !ok {
+			t.Fatalf("got %v want %v", err, "Errors")
+		}
+
+		want := Errors{
+			{Line: 7, Message: "'courier.dispatch' must be one of matched, fifo: lifo"},
+			{Line: 13, Message: "'overflowShelves[0].name' must be unique, 'Hot shelf' is already used by 'shelves[0]'"},
+			{Line: 15, Message: "'overflowShelves[0].discardPolicy' must be one of random, lowestValue, soonestToExpire, oldest, highestDecayRate: newest"},
+			{Line: 18, Message: "'overflowShelves[2].name' must be unique, 'Overflow shelf' is already used by 'overflowShelves[1]'"},
+			{Line: 23, Message: "'compatibility[0].shelves[0].temp' is not the temperature of a shelf: cold"},
+			{Line: 25, Message: "'compatibility[0].shelves[1].name' is not the name of an overflow shelf: Cold overflow shelf"},
+		}
+
+		if len(errs) != len(want) {
+			t.Fatalf("got %v want %v", errs, want)
+		}
+
+		for i := range want {
+			if errs[i] != want[i] {
+				t.Errorf("got %v want %v", errs[i], want[i])
+			}
+		}
+	})
+
+	t.Run("Invalid_OverflowShelves", func(t *testing.T) {
+		_, err := Parse([]byte(valid + `overflowShelves:
+  - name: Hot overflow shelf
+    temps: [hot, hto]
+    cap: 1
+`))
+
+		errs, ok := err.(Errors)
+		if !ok {
+			t.Fatalf("got %v want %v", err, "Errors")
+		}
+
+		want := Errors{
+			{Line: 18, Message: "'overflowShelf' cannot be given together with 'overflowShelves'"},
+			{Line: 25, Message: "'overflowShelves[0].temps[1]' is not the temperature of a shelf or a compatibility entry: hto"},
+		}
+
+		if len(errs) != len(want) {
+			t.Fatalf("got %v want %v", errs, want)
+		}
+
+		for i := range want {
+			if errs[i] != want[i] {
+				t.Errorf("got %v want %v", errs[i], want[i])
+			}
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	config, err := Parse([]byte(valid))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config.Order.IngestionRate.Count = 0
+
+	if got, want := config.Validate(), (Errors{{Message: "'order.ingestionRate.count' must be positive"}}); got == nil || got.Error() != want.Error() {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
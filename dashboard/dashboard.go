@@ -0,0 +1,138 @@
+// Package dashboard draws the kitchen shelves in the terminal, so operators can watch the kitchen instead of reading logs
+package dashboard
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"delivery/kitchen"
+)
+
+const (
+	// width of the value and occupancy bars
+	barWidth = 20
+	// window the incoming rate is calculated over
+	rateWindow = 10 * time.Second
+	// clears the terminal and moves the cursor to the top left corner
+	clearScreen = "\033[H\033[2J"
+)
+
+// Dashboard draws the state of the kitchen
+type Dashboard struct {
+	kitchen  *kitchen.Kitchen
+	clock    kitchen.Clock
+	received []time.Time
+	mutex    sync.Mutex
+	// held while a frame is drawn, so nothing is drawn once the dashboard is stopped
+	drawing sync.Mutex
+	stopped bool
+}
+
+// New creates new dashboard for the kitchen
+func New(clock kitchen.Clock, k *kitchen.Kitchen) *Dashboard {
+	d := &Dashboard{
+		kitchen: k,
+		clock:   clock,
+	}
+
+	k.Subscribe(func(event kitchen.Event) {
+		if event.Type == kitchen.OrderReceived {
+			d.mutex.Lock()
+			d.received = append(d.received, event.Time)
+			d.mutex.Unlock()
+		}
+	})
+
+	return d
+}
+
+// Run redraws the dashboard on every tick of the interval until the dashboard is stopped or the kitchen is closed
+func (d *Dashboard) Run(w io.Writer, interval time.Duration) {
+	var redraw func()
+	redraw = func() {
+		d.drawing.Lock()
+		defer d.drawing.Unlock()
+
+		select {
+		case <-d.kitchen.Done():
+			return
+		default:
+		}
+		if d.stopped {
+			return
+		}
+
+		io.WriteString(w, clearScreen)
+		d.Render(w)
+
+		d.clock.AfterFunc(interval, redraw)
+	}
+
+	redraw()
+}
+
+// Stop stops redrawing the dashboard, the frame being drawn is finished before Stop returns
+func (d *Dashboard) Stop() {
+	d.drawing.Lock()
+	d.stopped = true
+	d.drawing.Unlock()
+}
+
+// Render draws one frame of the dashboard
+func (d *Dashboard) Render(w io.Writer) {
+	stats := d.kitchen.Stats()
+
+	state := "running"
+	if d.kitchen.IsOnPause() {
+		state = "paused"
+	}
+
+	fmt.Fprintf(w, "Kitchen %s at %s\n", state, d.clock.Now().Format("15:04:05"))
+	fmt.Fprintf(w, "Incoming: %.1f orders/s   Pending couriers: %d   Awaiting courier: %d\n", d.incomingRate(), d.kitchen.PendingCouriers(), d.kitchen.AwaitingOrders())
+	fmt.Fprintf(w, "Delivered: %d   Expired: %d   Discarded: %d   Rejected: %d\n", stats.Delivered, stats.Expired, stats.Discarded, stats.Rejected)
+
+	now := d.clock.Now()
+	for _, shelf := range d.kitchen.AllShelves() {
+		orders := shelf.Orders()
+
+		fmt.Fprintf(w, "\n%s [%s] %s %d/%d\n", shelf.Name, shelf.Temperature, bar(float64(len(orders))/float64(shelf.Capacity)), len(orders), shelf.Capacity)
+
+		for _, order := range orders {
+			value := order.GetInherentValue(now)
+			fmt.Fprintf(w, "  %-8.8s %-20.20s %s %.2f\n", order.ID, order.Name, bar(value), value)
+		}
+	}
+}
+
+// incomingRate returns the number of orders received per second over the rate window
+func (d *Dashboard) incomingRate() float64 {
+	since := d.clock.Now().Add(-rateWindow)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	i := 0
+	for i < len(d.received) && d.received[i].Before(since) {
+		i++
+	}
+	d.received = d.received[i:]
+
+	return float64(len(d.received)) / rateWindow.Seconds()
+}
+
+// bar draws the fraction as a horizontal bar
+func bar(fraction float64) string {
+	if fraction < 0 || math.IsNaN(fraction) {
+		fraction = 0
+	} else if fraction > 1 {
+		fraction = 1
+	}
+
+	filled := int(fraction*barWidth + 0.5)
+
+	return strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+}
@@ -0,0 +1,140 @@
+package dashboard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	c "delivery/config"
+	"delivery/kitchen"
+)
+
+func init() {
+	c.Init("../config.yml")
+}
+
+func TestDashboard(t *testing.T) {
+	t.Run("Render", func(t *testing.T) {
+		clock := kitchen.NewVirtualClock(time.Now())
+
+		k := kitchen.New(
+			clock,
+			map[string]*kitchen.Shelf{
+				"hot": kitchen.NewShelf(clock, "Hot shelf", "hot", 10, 1),
+			},
+			[]*kitchen.Shelf{kitchen.NewShelf(clock, "Overflow shelf", "any", 15, 2)},
+		)
+		d := New(clock, k)
+
+		order := &kitchen.Order{ID: "1", Name: "Cheese Pizza", Temperature: "hot", ShelfLife: 300, DecayRate: 0.5}
+		k.PlaceOrder(order)
+		k.CreateCourier(order)
+
+		var buf bytes.Buffer
+		d.Render(&buf)
+		got := buf.String()
+
+		for _, want := range []string{
+			"Incoming: 0.1 orders/s",
+			"Pending couriers: 1",
+			"Hot shelf [hot]",
+			"1/10",
+			"Cheese Pizza",
+			"Overflow shelf [any]",
+			"0/15",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("got %q want %q", got, want)
+			}
+		}
+	})
+
+	t.Run("Run", func(t *testing.T) {
+		clock := kitchen.NewVirtualClock(time.Now())
+
+		k := kitchen.New(
+			clock,
+			map[string]*kitchen.Shelf{},
+			[]*kitchen.Shelf{kitchen.NewShelf(clock, "Overflow shelf", "any", 15, 2)},
+		)
+
+		var buf bytes.Buffer
+		New(clock, k).Run(&buf, time.Second)
+		clock.Advance(2 * time.Second)
+
+		want := 3
+		got := strings.Count(buf.String(), clearScreen)
+		if got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+	})
+
+	t.Run("bar", func(t *testing.T) {
+		cases := []struct {
+			fraction float64
+			want     int
+		}{
+			{0, 0},
+			{0.5, 10},
+			{1, 20},
+			{2, 20},
+			{-1, 0},
+		}
+
+		for _, tc := range cases {
+			got := strings.Count(bar(tc.fraction), "█")
+			if got != tc.want {
+				t.Errorf("got %v want %v", got, tc.want)
+			}
+		}
+	})
+
+	t.Run("Run_Closed", func(t *testing.T) {
+		clock := kitchen.NewVirtualClock(time.Now())
+
+		k := kitchen.New(
+			clock,
+			map[string]*kitchen.Shelf{},
+			[]*kitchen.Shelf{kitchen.NewShelf(clock, "Overflow shelf", "any", 15, 2)},
+		)
+		d := New(clock, k)
+
+		var buf bytes.Buffer
+		d.Run(&buf, time.Second)
+		clock.Advance(time.Second)
+
+		if got, want := strings.Count(buf.String(), clearScreen), 2; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+
+		k.Close()
+		buf.Reset()
+		clock.Advance(2 * time.Second)
+
+		if buf.Len() != 0 || clock.Pending() != 0 {
+			t.Errorf("got %q and %d timers want %v", buf.String(), clock.Pending(), "nothing drawn")
+		}
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		clock := kitchen.NewVirtualClock(time.Now())
+
+		k := kitchen.New(
+			clock,
+			map[string]*kitchen.Shelf{},
+			[]*kitchen.Shelf{kitchen.NewShelf(clock, "Overflow shelf", "any", 15, 2)},
+		)
+		d := New(clock, k)
+
+		var buf bytes.Buffer
+		d.Run(&buf, time.Second)
+		d.Stop()
+		buf.Reset()
+		clock.Advance(2 * time.Second)
+
+		if buf.Len() != 0 {
+			t.Errorf("got %q want %q", buf.String(), "")
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"delivery/generator"
+)
+
+// generate writes synthetic orders made of the menu items to the file or the stdout, the output can be read as the orders file
+func generate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	menuPath := flags.String("menu", "menu.json", "Menu file path")
+	count := flags.Int("n", 100, "Number of orders")
+	arrivals := flags.String("arrivals", "poisson", "Arrivals of the orders: 'poisson' or 'uniform'")
+	rate := flags.Float64("rate", 2, "Orders per second")
+	profile := flags.String("profile", "", "Poisson arrivals with the rate changing over time, e.g. '0s=1,30m=5,1h=1' (overrides -arrivals and -rate)")
+	seed := flags.Int64("seed", 0, "Random seed, random by default")
+	outputPath := flags.String("o", "", "Output file path, the stdout by default")
+	ndjson := flags.Bool("ndjson", false, "Write the orders as JSON lines instead of a JSON array")
+	flags.Parse(args)
+
+	if *count < 0 {
+		return fmt.Errorf("number of orders cannot be negative")
+	}
+
+	menu, err := generator.ReadMenu(*menuPath)
+	if err != nil {
+		return errors.Wrap(err, "Cannot read menu")
+	}
+
+	var a generator.Arrivals
+	switch {
+	case *profile != "":
+		a, err = generator.ParseProfile(*profile)
+		if err != nil {
+			return err
+		}
+	case *rate <= 0:
+		return fmt.Errorf("rate must be positive")
+	case *arrivals == "poisson":
+		a = generator.PoissonArrivals{Rate: *rate}
+	case *arrivals == "uniform":
+		a = generator.UniformArrivals{Rate: *rate}
+	default:
+		return fmt.Errorf("unknown arrivals '%s'", *arrivals)
+	}
+
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		set = set || f.Name == "seed"
+	})
+	if !set {
+		*seed = time.Now().UnixNano()
+	}
+
+	log.Infof("Random seed: %d", *seed)
+
+	g, err := generator.New(menu, a, *seed)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *outputPath != "" {
+		file, err := os.Create(*outputPath)
+		if err != nil {
+			return errors.Wrap(err, "Cannot create output file")
+		}
+		defer file.Close()
+
+		w = file
+	}
+
+	return g.Write(w, *count, *ndjson)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "delivery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, format := range []string{"-ndjson=false", "-ndjson=true"} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(dir, "orders.json")
+			if err := generate([]string{"-n", "50", "-seed", "1", "-o", path, format}); err != nil {
+				t.Fatal(err)
+			}
+
+			orders, err := readOrders(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(orders) != 50 {
+				t.Errorf("got %v want %v", len(orders), 50)
+			}
+		})
+	}
+}
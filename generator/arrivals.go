@@ -0,0 +1,144 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Arrivals decides when the orders arrive
+type Arrivals interface {
+	// Next returns the time between the order arrived at the elapsed time and the next one
+	Next(elapsed time.Duration, rnd *rand.Rand) time.Duration
+}
+
+// PoissonArrivals is a Poisson process, the orders arrive independently at the average rate
+type PoissonArrivals struct {
+	// orders per second
+	Rate float64
+}
+
+// Next -
+func (a PoissonArrivals) Next(_ time.Duration, rnd *rand.Rand) time.Duration {
+	return seconds(rnd.ExpFloat64() / a.Rate)
+}
+
+// UniformArrivals is the flat rate, the orders arrive at equal intervals
+type UniformArrivals struct {
+	// orders per second
+	Rate float64
+}
+
+// Next -
+func (a UniformArrivals) Next(time.Duration, *rand.Rand) time.Duration {
+	return seconds(1 / a.Rate)
+}
+
+// RateStep is the rate the orders arrive at from the time
+type RateStep struct {
+	// time from the start of the orders
+	From time.Duration
+	// orders per second
+	Rate float64
+}
+
+// ProfileArrivals is a Poisson process with the rate changing over time, e.g. to model a lunch rush.
+// The rate of a step lasts until the next step, the rate before the first step is the rate of the first step
+type ProfileArrivals struct {
+	Steps []RateStep
+}
+
+// NewProfileArrivals creates the arrivals with the rate changing by steps, at least one step has to have a positive rate
+func NewProfileArrivals(steps []RateStep) (*ProfileArrivals, error) {
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("rate profile must have steps")
+	}
+
+	sorted := make([]RateStep, len(steps))
+	copy(sorted, steps)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].From < sorted[j].From
+	})
+
+	positive := false
+	for _, step := range sorted {
+		if step.Rate < 0 {
+			return nil, fmt.Errorf("rate of the profile cannot be negative, got %v", step.Rate)
+		}
+		positive = positive || step.Rate > 0
+	}
+
+	if !positive {
+		return nil, fmt.Errorf("rate profile must have a positive rate")
+	}
+
+	if sorted[len(sorted)-1].Rate == 0 {
+		return nil, fmt.Errorf("last rate of the profile must be positive, otherwise the orders stop arriving")
+	}
+
+	return &ProfileArrivals{Steps: sorted}, nil
+}
+
+// ParseProfile parses the rate profile written as comma separated 'from=rate' steps, e.g. '0s=1,30m=5,1h=1'
+func ParseProfile(value string) (*ProfileArrivals, error) {
+	var steps []RateStep
+	for _, part := range strings.Split(value, ",") {
+		fields := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid rate step '%s', want 'from=rate'", part)
+		}
+
+		from, err := time.ParseDuration(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid start of the rate step '%s': %v", part, err)
+		}
+
+		rate, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate of the rate step '%s': %v", part, err)
+		}
+
+		steps = append(steps, RateStep{From: from, Rate: rate})
+	}
+
+	return NewProfileArrivals(steps)
+}
+
+// Next draws the arrivals at the max rate of the profile and thins them to the rate at the time of the arrival
+func (a *ProfileArrivals) Next(elapsed time.Duration, rnd *rand.Rand) time.Duration {
+	max := 0.0
+	for _, step := range a.Steps {
+		max = math.Max(max, step.Rate)
+	}
+
+	next := elapsed
+	for {
+		next += seconds(rnd.ExpFloat64() / max)
+
+		if rnd.Float64()*max < a.rate(next) {
+			return next - elapsed
+		}
+	}
+}
+
+// rate returns the rate at the time
+func (a *ProfileArrivals) rate(elapsed time.Duration) float64 {
+	rate := a.Steps[0].Rate
+	for _, step := range a.Steps {
+		if step.From > elapsed {
+			break
+		}
+		rate = step.Rate
+	}
+
+	return rate
+}
+
+// seconds converts the seconds to the duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestArrivals(t *testing.T) {
+	profile, err := NewProfileArrivals([]RateStep{{From: 0, Rate: 1}, {From: time.Hour, Rate: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		arrivals Arrivals
+		elapsed  time.Duration
+		want     time.Duration
+	}{
+		{"Poisson", PoissonArrivals{Rate: 4}, 0, 250 * time.Millisecond},
+		{"Uniform", UniformArrivals{Rate: 4}, 0, 250 * time.Millisecond},
+		{"Profile", profile, 0, time.Second},
+		{"Profile_Rush", profile, time.Hour, 100 * time.Millisecond},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rnd := rand.New(rand.NewSource(1))
+
+			n := 10000
+			var total time.Duration
+			for i := 0; i < n; i++ {
+				total += tc.arrivals.Next(tc.elapsed, rnd)
+			}
+
+			got := total / time.Duration(n)
+			if math.Abs(float64(got-tc.want)) > 0.05*float64(tc.want) {
+				t.Errorf("got %v want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseProfile(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   string
+		want    []RateStep
+		wantErr bool
+	}{
+		{"Steps", "30m=5, 0s=1,1h=1", []RateStep{{0, 1}, {30 * time.Minute, 5}, {time.Hour, 1}}, false},
+		{"Format_Negative", "0s:1", nil, true},
+		{"Duration_Negative", "noon=1", nil, true},
+		{"Rate_Negative", "0s=-1", nil, true},
+		{"Stop_Negative", "0s=1,1h=0", nil, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseProfile(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got %v want error %v", err, tc.wantErr)
+			}
+
+			if tc.wantErr {
+				return
+			}
+
+			if len(got.Steps) != len(tc.want) {
+				t.Fatalf("got %v want %v", got.Steps, tc.want)
+			}
+
+			for i := range tc.want {
+				if got.Steps[i] != tc.want[i] {
+					t.Errorf("got %v want %v", got.Steps, tc.want)
+				}
+			}
+		})
+	}
+}
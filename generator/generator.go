@@ -0,0 +1,211 @@
+// Package generator produces synthetic orders from a menu, arriving by a Poisson process or a custom rate, so the kitchen can be stressed with realistic load
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"delivery/kitchen"
+)
+
+// Range is the closed interval the value is drawn from uniformly
+type Range struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// Item is the menu item the orders are made of
+type Item struct {
+	Name        string `json:"name"`
+	Temperature string `json:"temp"`
+	// shelf life of the orders (seconds), rounded to whole seconds
+	ShelfLife Range `json:"shelfLife"`
+	DecayRate Range `json:"decayRate"`
+	// curve the value of the orders follows, linear by default
+	Decay *kitchen.DecaySpec `json:"decay,omitempty"`
+	// how often the item is ordered relative to the other items, 1 by default
+	Weight float64 `json:"weight,omitempty"`
+}
+
+// Generator produces the orders
+type Generator struct {
+	menu     []Item
+	arrivals Arrivals
+	rand     *rand.Rand
+	elapsed  time.Duration
+	total    float64
+	started  bool
+}
+
+// New creates the generator of the orders made of the menu items, the same seed produces the same orders
+func New(menu []Item, arrivals Arrivals, seed int64) (*Generator, error) {
+	if err := validateMenu(menu); err != nil {
+		return nil, err
+	}
+
+	g := &Generator{
+		menu:     menu,
+		arrivals: arrivals,
+		rand:     rand.New(rand.NewSource(seed)),
+	}
+
+	for _, item := range menu {
+		g.total += weight(item)
+	}
+
+	return g, nil
+}
+
+// Next returns the next order, its 'receivedAt' is the offset from the first order
+func (g *Generator) Next() *kitchen.Order {
+	if g.started {
+		g.elapsed += g.arrivals.Next(g.elapsed, g.rand)
+	}
+	g.started = true
+
+	item := g.item()
+
+	return &kitchen.Order{
+		ID:          g.id(),
+		Name:        item.Name,
+		Temperature: item.Temperature,
+		ShelfLife:   int(math.Round(g.draw(item.ShelfLife))),
+		DecayRate:   math.Round(g.draw(item.DecayRate)*100) / 100,
+		Decay:       item.Decay,
+		ReceivedAt:  &kitchen.ReceivedAt{Offset: g.elapsed.Round(time.Millisecond)},
+	}
+}
+
+// Generate returns the next count orders
+func (g *Generator) Generate(count int) []*kitchen.Order {
+	orders := make([]*kitchen.Order, 0, count)
+	for i := 0; i < count; i++ {
+		orders = append(orders, g.Next())
+	}
+
+	return orders
+}
+
+// item picks the menu item by its weight
+func (g *Generator) item() Item {
+	pick := g.rand.Float64() * g.total
+	for _, item := range g.menu {
+		if pick -= weight(item); pick < 0 {
+			return item
+		}
+	}
+
+	return g.menu[len(g.menu)-1]
+}
+
+// draw returns the value from the range
+func (g *Generator) draw(r Range) float64 {
+	return r.Min + g.rand.Float64()*(r.Max-r.Min)
+}
+
+// id returns the random UUID
+func (g *Generator) id() string {
+	b := make([]byte, 16)
+	g.rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// ReadMenu reads the JSON array of the menu items from the file
+func ReadMenu(path string) ([]Item, error) {
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var menu []Item
+	if err := json.Unmarshal(file, &menu); err != nil {
+		return nil, err
+	}
+
+	return menu, validateMenu(menu)
+}
+
+// validateMenu checks that the menu items produce valid orders
+func validateMenu(menu []Item) error {
+	if len(menu) == 0 {
+		return fmt.Errorf("menu must have items")
+	}
+
+	for _, item := range menu {
+		switch {
+		case item.Name == "":
+			return fmt.Errorf("menu item must have 'name'")
+		case item.Temperature == "":
+			return fmt.Errorf("menu item '%s' must have 'temp'", item.Name)
+		case item.ShelfLife.Min < 1 || item.ShelfLife.Max < item.ShelfLife.Min:
+			return fmt.Errorf("'shelfLife' of menu item '%s' must be a range of positive seconds", item.Name)
+		case item.DecayRate.Min < 0 || item.DecayRate.Max < item.DecayRate.Min:
+			return fmt.Errorf("'decayRate' of menu item '%s' must be a non-negative range", item.Name)
+		case item.Weight < 0:
+			return fmt.Errorf("'weight' of menu item '%s' cannot be negative", item.Name)
+		}
+
+		if _, err := kitchen.NewDecayModel(item.Decay); err != nil {
+			return errors.Wrapf(err, "Invalid decay of menu item '%s'", item.Name)
+		}
+	}
+
+	return nil
+}
+
+// weight returns the weight of the item
+func weight(item Item) float64 {
+	if item.Weight == 0 {
+		return 1
+	}
+
+	return item.Weight
+}
+
+// Write writes the next count orders as a JSON array, or as JSON lines if ndjson is set.
+// The orders are written as they are generated, so the count is not limited by memory
+func (g *Generator) Write(w io.Writer, count int, ndjson bool) error {
+	if ndjson {
+		encoder := json.NewEncoder(w)
+		for i := 0; i < count; i++ {
+			if err := encoder.Encode(g.Next()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	for i := 0; i < count; i++ {
+		contents, err := json.MarshalIndent(g.Next(), "  ", "  ")
+		if err != nil {
+			return err
+		}
+
+		separator := ",\n  "
+		if i == 0 {
+			separator = "\n  "
+		}
+
+		if _, err := fmt.Fprintf(w, "%s%s", separator, contents); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "\n]\n")
+	return err
+}
@@ -0,0 +1,117 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"delivery/kitchen"
+)
+
+var menu = []Item{
+	{Name: "Pizza", Temperature: "hot", ShelfLife: Range{200, 300}, DecayRate: Range{0.4, 0.6}, Weight: 3},
+	{Name: "Ice Cream", Temperature: "frozen", ShelfLife: Range{100, 100}, DecayRate: Range{0.5, 0.5}},
+}
+
+func TestGenerator(t *testing.T) {
+	t.Run("Next", func(t *testing.T) {
+		g, err := New(menu, UniformArrivals{Rate: 2}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		names := map[string]int{}
+		for i, order := range g.Generate(1000) {
+			names[order.Name]++
+
+			if order.ReceivedAt == nil || order.ReceivedAt.Offset.Seconds() != float64(i)/2 {
+				t.Fatalf("got %v want %v", order.ReceivedAt, float64(i)/2)
+			}
+
+			if order.Name == "Pizza" && (order.ShelfLife < 200 || order.ShelfLife > 300 || order.DecayRate < 0.4 || order.DecayRate > 0.6) {
+				t.Errorf("got %+v want %+v", order, menu[0])
+			}
+
+			if order.Name == "Ice Cream" && (order.ShelfLife != 100 || order.DecayRate != 0.5 || order.Temperature != "frozen") {
+				t.Errorf("got %+v want %+v", order, menu[1])
+			}
+		}
+
+		if names["Pizza"] < 700 || names["Pizza"] > 800 {
+			t.Errorf("got %v want %v", names["Pizza"], "about 750 by weight")
+		}
+	})
+
+	t.Run("Seed", func(t *testing.T) {
+		a, _ := New(menu, PoissonArrivals{Rate: 2}, 42)
+		b, _ := New(menu, PoissonArrivals{Rate: 2}, 42)
+
+		for i := 0; i < 10; i++ {
+			got, want := a.Next(), b.Next()
+			if got.ID != want.ID || got.Name != want.Name || got.ReceivedAt.Offset != want.ReceivedAt.Offset {
+				t.Errorf("got %+v want %+v", got, want)
+			}
+		}
+	})
+
+	t.Run("New_Negative", func(t *testing.T) {
+		cases := []struct {
+			name string
+			menu []Item
+		}{
+			{"Empty", nil},
+			{"Temperature", []Item{{Name: "Pizza", ShelfLife: Range{1, 1}}}},
+			{"ShelfLife", []Item{{Name: "Pizza", Temperature: "hot", ShelfLife: Range{300, 200}}}},
+			{"Decay", []Item{{Name: "Pizza", Temperature: "hot", ShelfLife: Range{1, 1}, Decay: &kitchen.DecaySpec{Model: "cubic"}}}},
+		}
+
+		for _, tc := range cases {
+			if _, err := New(tc.menu, PoissonArrivals{Rate: 1}, 1); err == nil {
+				t.Errorf("%s: got %v want error", tc.name, err)
+			}
+		}
+	})
+
+	t.Run("Write", func(t *testing.T) {
+		for _, ndjson := range []bool{false, true} {
+			g, _ := New(menu, PoissonArrivals{Rate: 2}, 1)
+
+			var buf bytes.Buffer
+			if err := g.Write(&buf, 3, ndjson); err != nil {
+				t.Fatal(err)
+			}
+
+			var orders []kitchen.Order
+			if ndjson {
+				decoder := json.NewDecoder(&buf)
+				for decoder.More() {
+					var order kitchen.Order
+					if err := decoder.Decode(&order); err != nil {
+						t.Fatal(err)
+					}
+					orders = append(orders, order)
+				}
+			} else if err := json.Unmarshal(buf.Bytes(), &orders); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(orders) != 3 {
+				t.Errorf("got %v want %v", len(orders), 3)
+			}
+		}
+	})
+
+	t.Run("Write_Empty", func(t *testing.T) {
+		g, _ := New(menu, PoissonArrivals{Rate: 2}, 1)
+
+		var buf bytes.Buffer
+		if err := g.Write(&buf, 0, false); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := strings.TrimSpace(buf.String()); got != "[\n]" {
+			t.Errorf("got %q want %q", got, "[\n]")
+		}
+	})
+}
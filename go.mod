@@ -5,5 +5,5 @@ go 1.14
 require (
 	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.6.0
-	gopkg.in/yaml.v2 v2.3.0
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,198 @@
+package kitchen
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// Clock is a source of time for the kitchen, its shelves and couriers
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// AfterFunc waits for the duration to elapse and then calls f
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a function call scheduled on a clock
+type Timer interface {
+	// Stop prevents the timer from firing, returns false if the timer has already fired or been stopped
+	Stop() bool
+}
+
+// RealClock is the wall clock
+type RealClock struct{}
+
+// Now returns the current local time
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// AfterFunc calls f in its own goroutine after the duration elapses
+func (RealClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// VirtualClock is a clock that moves forward only when it is advanced, scheduled functions are called in the order of their time
+type VirtualClock struct {
+	now    time.Time
+	seq    uint64
+	timers virtualTimers
+	mutex  sync.Mutex
+}
+
+// NewVirtualClock creates new virtual clock that starts at the given time
+func NewVirtualClock(start time.Time) *VirtualClock {
+	return &VirtualClock{now: start}
+}
+
+// Now returns the current virtual time
+func (c *VirtualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+// AfterFunc schedules f to be called when the clock is advanced past the duration
+func (c *VirtualClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if d < 0 {
+		d = 0
+	}
+
+	c.seq++
+	t := &virtualTimer{
+		clock: c,
+		at:    c.now.Add(d),
+		seq:   c.seq,
+		f:     f,
+	}
+	heap.Push(&c.timers, t)
+
+	return t
+}
+
+// Advance moves the clock forward by the duration and calls every function scheduled up to the new time
+func (c *VirtualClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	until := c.now.Add(d)
+	c.mutex.Unlock()
+
+	for {
+		if !c.fireNext(until) {
+			break
+		}
+	}
+
+	c.mutex.Lock()
+	if c.now.Before(until) {
+		c.now = until
+	}
+	c.mutex.Unlock()
+}
+
+// Run advances the clock until there are no scheduled functions left
+func (c *VirtualClock) Run() {
+	for {
+		c.mutex.Lock()
+		if len(c.timers) == 0 {
+			c.mutex.Unlock()
+			return
+		}
+		next := c.timers[0].at
+		c.mutex.Unlock()
+
+		c.fireNext(next)
+	}
+}
+
+// Pending returns the number of scheduled functions that have not been called yet
+func (c *VirtualClock) Pending() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.timers)
+}
+
+// fireNext calls the earliest function scheduled not later than the given time
+func (c *VirtualClock) fireNext(until time.Time) bool {
+	c.mutex.Lock()
+	if len(c.timers) == 0 || c.timers[0].at.After(until) {
+		c.mutex.Unlock()
+		return false
+	}
+
+	t := heap.Pop(&c.timers).(*virtualTimer)
+	if t.at.After(c.now) {
+		c.now = t.at
+	}
+	c.mutex.Unlock()
+
+	// the function is called without the lock, so it can schedule new functions
+	t.f()
+
+	return true
+}
+
+type virtualTimer struct {
+	clock *VirtualClock
+	at    time.Time
+	seq   uint64
+	f     func()
+	index int
+}
+
+// Stop removes the function from the clock schedule
+func (t *virtualTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+
+	if t.index < 0 {
+		return false
+	}
+
+	heap.Remove(&t.clock.timers, t.index)
+
+	return true
+}
+
+// virtualTimers is a min-heap of timers ordered by time and then by scheduling order
+type virtualTimers []*virtualTimer
+
+func (h virtualTimers) Len() int {
+	return len(h)
+}
+
+func (h virtualTimers) Less(i, j int) bool {
+	if h[i].at.Equal(h[j].at) {
+		return h[i].seq < h[j].seq
+	}
+
+	return h[i].at.Before(h[j].at)
+}
+
+func (h virtualTimers) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *virtualTimers) Push(x interface{}) {
+	t := x.(*virtualTimer)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *virtualTimers) Pop() interface{} {
+	old := *h
+	n := len(old)
+	t := old[n-1]
+	old[n-1] = nil
+	t.index = -1
+	*h = old[:n-1]
+
+	return t
+}
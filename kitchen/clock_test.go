@@ -0,0 +1,74 @@
+package kitchen
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVirtualClock(t *testing.T) {
+	t.Run("Advance", func(t *testing.T) {
+		start := time.Now()
+		clock := NewVirtualClock(start)
+
+		var calls []int
+		clock.AfterFunc(2*time.Second, func() { calls = append(calls, 2) })
+		clock.AfterFunc(time.Second, func() { calls = append(calls, 1) })
+		clock.AfterFunc(3*time.Second, func() { calls = append(calls, 3) })
+
+		clock.Advance(2 * time.Second)
+
+		if len(calls) != 2 || calls[0] != 1 || calls[1] != 2 {
+			t.Errorf("got %v want %v", calls, []int{1, 2})
+		}
+
+		want := start.Add(2 * time.Second)
+		got := clock.Now()
+		if !got.Equal(want) {
+			t.Errorf("got %v want %v", got, want)
+		}
+
+		if clock.Pending() != 1 {
+			t.Errorf("got %v want %v", clock.Pending(), 1)
+		}
+	})
+
+	t.Run("Advance_Nested", func(t *testing.T) {
+		start := time.Now()
+		clock := NewVirtualClock(start)
+
+		var calledAt time.Time
+		clock.AfterFunc(time.Second, func() {
+			clock.AfterFunc(time.Second, func() {
+				calledAt = clock.Now()
+			})
+		})
+
+		clock.Advance(5 * time.Second)
+
+		want := start.Add(2 * time.Second)
+		if !calledAt.Equal(want) {
+			t.Errorf("got %v want %v", calledAt, want)
+		}
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		clock := NewVirtualClock(time.Now())
+
+		called := false
+		timer := clock.AfterFunc(time.Second, func() { called = true })
+
+		if !timer.Stop() {
+			t.Errorf("got %v want %v", false, true)
+		}
+
+		if timer.Stop() {
+			t.Errorf("got %v want %v", true, false)
+		}
+
+		clock.Advance(time.Second)
+
+		if called {
+			t.Errorf("got %v want %v", called, false)
+		}
+	})
+}
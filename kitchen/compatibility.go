@@ -0,0 +1,134 @@
+package kitchen
+
+import "sort"
+
+// Compatibility lists by order temperature the shelves the order may sit on when the shelf of its temperature is full
+type Compatibility map[string][]CompatibleShelf
+
+// CompatibleShelf is the shelf the order may sit on and the penalty for it
+type CompatibleShelf struct {
+	// temperature of the shelf
+	Temperature string
+	// name of the overflow shelf, the order on the overflow shelf decays with the multiplier instead of the temperature shelf
+	Name string
+	// the decay modifier of the shelf is multiplied by it for the order
+	DecayMultiplier float64
+}
+
+// compatibleShelves returns the temperature shelves the order of the temperature may sit on with their multipliers,
+// the least bad ones first: the shelves are sorted by the decay modifier of the shelf multiplied by the multiplier
+func (k *Kitchen) compatibleShelves(temp string) []CompatibleShelf {
+	var result []CompatibleShelf
+	for _, compatible := range k.compatibility[temp] {
+		if _, ok := k.Shelves[compatible.Temperature]; ok && compatible.Name == "" && compatible.Temperature != temp {
+			result = append(result, compatible)
+		}
+	}
+
+	modifier := func(compatible CompatibleShelf) float64 {
+		return float64(k.Shelves[compatible.Temperature].decayModifier) * compatible.DecayMultiplier
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if modifier(result[i]) != modifier(result[j]) {
+			return modifier(result[i]) < modifier(result[j])
+		}
+
+		return result[i].Temperature < result[j].Temperature
+	})
+
+	return result
+}
+
+// decayMultiplier returns the multiplier of the decay modifier of the shelf for the order of the temperature, 1 if the order is not penalised on the shelf
+func (k *Kitchen) decayMultiplier(temp string, shelf *Shelf) float64 {
+	overflow := k.isOverflowShelf(shelf)
+	if !overflow && shelf.Temperature == temp {
+		return 1
+	}
+
+	for _, compatible := range k.compatibility[temp] {
+		if overflow && compatible.Name == shelf.Name || !overflow && compatible.Name == "" && compatible.Temperature == shelf.Temperature {
+			return compatible.DecayMultiplier
+		}
+	}
+
+	return 1
+}
+
+// placeOnCompatibleShelf adds the order to the least bad compatible shelf that has an empty seat,
+// the overflow shelves of the compatibility are left to the overflow placement
+func (k *Kitchen) placeOnCompatibleShelf(order *Order) (*Shelf, bool) {
+	for _, compatible := range k.compatibleShelves(order.Temperature) {
+		shelf := k.Shelves[compatible.Temperature]
+		if shelf.addOrder(order, compatible.DecayMultiplier) {
+			k.logger.WithFields(k.getExtraFileds()).Infof("Order placed on compatible %s: %s", shelf.Name, order.ID)
+			return shelf, true
+		}
+	}
+
+	return nil, false
+}
+
+// compatibleShelfFor returns the least bad compatible shelf with an empty seat on which the order of the temperature decays slower than with the modifier
+func (k *Kitchen) compatibleShelfFor(temp string, decayModifier float64) *Shelf {
+	for _, compatible := range k.compatibleShelves(temp) {
+		shelf := k.Shelves[compatible.Temperature]
+		if shelf.HasEmptySeats() && float64(shelf.decayModifier)*compatible.DecayMultiplier < decayModifier {
+			return shelf
+		}
+	}
+
+	return nil
+}
+
+// moveOrderToOwnShelf moves the penalised order on the shelf that is the closest to expiring back to the shelf of its temperature, the caller must hold the mutex
+func (k *Kitchen) moveOrderToOwnShelf(shelf *Shelf) bool {
+	var temps []string
+	for _, s := range k.GetAvailableShelves() {
+		if s != shelf {
+			temps = append(temps, s.Temperature)
+		}
+	}
+
+	if len(temps) == 0 {
+		return false
+	}
+
+	orderToMove := shelf.FindOrderByTemp(temps...)
+	if orderToMove == nil {
+		return false
+	}
+
+	return k.moveOrder(orderToMove.ID, shelf, k.Shelves[orderToMove.Temperature])
+}
+
+// moveOrderToCompatibleShelf moves the order on the overflow shelf that is the closest to expiring to a compatible shelf with an empty seat
+// on which the order decays slower, the caller must hold the mutex
+func (k *Kitchen) moveOrderToCompatibleShelf(overflowShelf *Shelf) bool {
+	if len(k.compatibility) == 0 {
+		return false
+	}
+
+	now := k.clock.Now()
+	var orderToMove *Order
+	var target *Shelf
+	for _, order := range overflowShelf.Orders() {
+		order := order
+		stints := order.Stints()
+		if len(stints) == 0 {
+			continue
+		}
+
+		shelf := k.compatibleShelfFor(order.Temperature, stints[len(stints)-1].DecayModifier)
+		if shelf != nil && (orderToMove == nil || order.remainingAge(now) < orderToMove.remainingAge(now)) {
+			orderToMove, target = &order, shelf
+		}
+	}
+
+	if orderToMove == nil {
+		return false
+	}
+
+	return k.moveOrder(orderToMove.ID, overflowShelf, target)
+}
@@ -0,0 +1,164 @@
+package kitchen
+
+import (
+	"testing"
+	"time"
+
+	c "delivery/config"
+)
+
+func TestCompatibility(t *testing.T) {
+	c.Config.Order.Age.Duration = time.Second
+
+	clock := NewVirtualClock(time.Now())
+
+	frozenOnColdAndHot := Compatibility{
+		"frozen": {
+			{Temperature: "hot", DecayMultiplier: 10},
+			{Temperature: "cold", DecayMultiplier: 3},
+		},
+	}
+
+	newKitchen := func(compatibility Compatibility) *Kitchen {
+		return New(
+			clock,
+			map[string]*Shelf{
+				"frozen": NewShelf(clock, "Frozen shelf", "frozen", 1, 1),
+				"cold":   NewShelf(clock, "Cold shelf", "cold", 5, 1),
+				"hot":    NewShelf(clock, "Hot shelf", "hot", 5, 1),
+			},
+			[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 5, 2)},
+			WithCompatibility(compatibility),
+		)
+	}
+
+	t.Run("PlaceOrder", func(t *testing.T) {
+		k := newKitchen(frozenOnColdAndHot)
+
+		k.PlaceOrder(&Order{ID: "1", Temperature: "frozen", ShelfLife: 100, DecayRate: 1})
+		order := &Order{ID: "2", Temperature: "frozen", ShelfLife: 100, DecayRate: 1}
+		k.PlaceOrder(order)
+
+		placed, shelf, ok := k.FindOrder(order.ID)
+		if !ok || shelf.Name != "Cold shelf" {
+			t.Fatalf("got %v want %v", shelf, "Cold shelf")
+		}
+
+		clock.Advance(10 * time.Second)
+
+		if got := placed.GetInherentValue(clock.Now()); got != 0.7 {
+			t.Errorf("got %v want %v", got, 0.7)
+		}
+
+		if !k.PickUpOrder(order) {
+			t.Errorf("got %v want %v", false, true)
+		}
+	})
+
+	t.Run("PlaceOrder_DecayModifier", func(t *testing.T) {
+		k := New(
+			clock,
+			map[string]*Shelf{
+				"frozen": NewShelf(clock, "Frozen shelf", "frozen", 1, 1),
+				"cold":   NewShelf(clock, "Cold shelf", "cold", 5, 2),
+				"hot":    NewShelf(clock, "Hot shelf", "hot", 5, 1),
+			},
+			[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 5, 2)},
+			WithCompatibility(Compatibility{
+				"frozen": {
+					{Temperature: "cold", DecayMultiplier: 1.5},
+					{Temperature: "hot", DecayMultiplier: 2},
+				},
+			}),
+		)
+
+		k.PlaceOrder(&Order{ID: "1", Temperature: "frozen", ShelfLife: 100, DecayRate: 1})
+		k.PlaceOrder(&Order{ID: "2", Temperature: "frozen", ShelfLife: 100, DecayRate: 1})
+
+		// the order decays 2x on the hot shelf and 3x on the cold one
+		if _, shelf, _ := k.FindOrder("2"); shelf.Name != "Hot shelf" {
+			t.Errorf("got %v want %v", shelf.Name, "Hot shelf")
+		}
+	})
+
+	t.Run("PlaceOrder_Overflow", func(t *testing.T) {
+		k := newKitchen(frozenOnColdAndHot)
+
+		order := &Order{ID: "1", Temperature: "cold", ShelfLife: 100, DecayRate: 1}
+		for i := 0; i < 5; i++ {
+			k.PlaceOrder(&Order{ID: string(rune('a' + i)), Temperature: "cold", ShelfLife: 100, DecayRate: 1})
+		}
+		k.PlaceOrder(order)
+
+		// there is no compatible shelf for cold orders
+		if _, shelf, _ := k.FindOrder(order.ID); shelf != k.OverflowShelves[0] {
+			t.Errorf("got %v want %v", shelf.Name, k.OverflowShelves[0].Name)
+		}
+	})
+	t.Run("FillVacancies_OwnShelf", func(t *testing.T) {
+		k := newKitchen(frozenOnColdAndHot)
+
+		first := &Order{ID: "1", Temperature: "frozen", ShelfLife: 100, DecayRate: 1}
+		k.PlaceOrder(first)
+		k.PlaceOrder(&Order{ID: "2", Temperature: "frozen", ShelfLife: 100, DecayRate: 1})
+
+		clock.Advance(10 * time.Second)
+		k.PickUpOrder(first)
+
+		// the penalised order goes back to the frozen shelf
+		if _, shelf, _ := k.FindOrder("2"); shelf.Name != "Frozen shelf" {
+			t.Fatalf("got %v want %v", shelf.Name, "Frozen shelf")
+		}
+
+		clock.Advance(10 * time.Second)
+
+		if order, _, _ := k.FindOrder("2"); order.GetInherentValue(clock.Now()) != 0.6 {
+			t.Errorf("got %v want %v", order.GetInherentValue(clock.Now()), 0.6)
+		}
+	})
+
+	t.Run("FillVacancies_Overflow", func(t *testing.T) {
+		k := newKitchen(Compatibility{
+			"frozen": {{Temperature: "cold", DecayMultiplier: 1.5}},
+		})
+
+		k.PlaceOrder(&Order{ID: "1", Temperature: "frozen", ShelfLife: 100, DecayRate: 1})
+		cold := &Order{ID: "a", Temperature: "cold", ShelfLife: 100, DecayRate: 1}
+		k.PlaceOrder(cold)
+		for i := 1; i < 5; i++ {
+			k.PlaceOrder(&Order{ID: string(rune('a' + i)), Temperature: "cold", ShelfLife: 100, DecayRate: 1})
+		}
+		k.PlaceOrder(&Order{ID: "2", Temperature: "frozen", ShelfLife: 100, DecayRate: 1})
+
+		if _, shelf, _ := k.FindOrder("2"); shelf != k.OverflowShelves[0] {
+			t.Fatalf("got %v want %v", shelf.Name, k.OverflowShelves[0].Name)
+		}
+
+		k.PickUpOrder(cold)
+
+		// the order decays slower on the cold shelf than on the overflow shelf
+		if _, shelf, _ := k.FindOrder("2"); shelf.Name != "Cold shelf" {
+			t.Errorf("got %v want %v", shelf.Name, "Cold shelf")
+		}
+	})
+
+	t.Run("PlaceOrder_OverflowShelf", func(t *testing.T) {
+		k := newKitchen(Compatibility{
+			"frozen": {{Name: "Overflow shelf", DecayMultiplier: 0.5}},
+		})
+
+		k.PlaceOrder(&Order{ID: "1", Temperature: "frozen", ShelfLife: 100, DecayRate: 1})
+		k.PlaceOrder(&Order{ID: "2", Temperature: "frozen", ShelfLife: 100, DecayRate: 1})
+
+		clock.Advance(10 * time.Second)
+
+		order, shelf, _ := k.FindOrder("2")
+		if shelf != k.OverflowShelves[0] {
+			t.Fatalf("got %v want %v", shelf.Name, k.OverflowShelves[0].Name)
+		}
+
+		if got := order.GetInherentValue(clock.Now()); got != 0.9 {
+			t.Errorf("got %v want %v", got, 0.9)
+		}
+	})
+}
@@ -0,0 +1,285 @@
+package kitchen
+
+import (
+	"time"
+
+	c "delivery/config"
+)
+
+// Courier picks up orders from the kitchen
+type Courier struct {
+	// the orders the courier was sent for, a courier picks up as many orders in one trip
+	Orders []*Order
+	// time the courier was requested
+	RequestedAt time.Time
+	// time the courier arrived at the kitchen
+	ArrivedAt time.Time
+	// ID of the courier, unique in the kitchen
+	id int
+	// expected arrival time of the courier
+	eta time.Time
+	// IDs of the orders the courier picked up
+	pickedUp map[string]bool
+	// fires when the courier arrives
+	timer Timer
+}
+
+// CreateCourier requests a courier for the order, the courier arrives after a random delay.
+// With the fleet the order waits for a free courier of the fleet
+func (k *Kitchen) CreateCourier(order *Order) {
+	if k.ctx.Err() != nil {
+		return
+	}
+
+	if k.fleet != nil {
+		k.couriersMutex.Lock()
+		k.awaitingOrders = append(k.awaitingOrders, order)
+		k.couriersMutex.Unlock()
+
+		k.assignCouriers()
+		return
+	}
+
+	k.couriersMutex.Lock()
+	k.couriers++
+	k.couriersMutex.Unlock()
+
+	k.dispatchCourier(&Courier{Orders: []*Order{order}, RequestedAt: k.clock.Now()})
+}
+
+// PendingCouriers returns the number of couriers that have not arrived yet
+func (k *Kitchen) PendingCouriers() int {
+	k.couriersMutex.Lock()
+	defer k.couriersMutex.Unlock()
+
+	return k.couriers
+}
+
+// WaitingCouriers returns the number of arrived couriers that wait for an order
+func (k *Kitchen) WaitingCouriers() int {
+	k.couriersMutex.Lock()
+	defer k.couriersMutex.Unlock()
+
+	return len(k.waitingCouriers)
+}
+
+// CourierArrival is the range of the time a courier needs to get to the kitchen, in units
+type CourierArrival struct {
+	Min  int
+	Max  int
+	Unit time.Duration
+}
+
+// courierDelay returns the random time a courier needs to get to the kitchen, the range is taken from the config unless the kitchen has its own
+func (k *Kitchen) courierDelay() time.Duration {
+	arrival := k.courierArrival
+	if arrival == nil {
+		arrival = &CourierArrival{
+			Min:  c.Config.Courier.Arrive.Min,
+			Max:  c.Config.Courier.Arrive.Max,
+			Unit: c.Config.Courier.Arrive.Duration,
+		}
+	}
+
+	randValue := k.rand.Intn(arrival.Max-arrival.Min+1) + arrival.Min
+
+	return time.Duration(randValue) * arrival.Unit
+}
+
+// dispatchCourier sends the courier to the kitchen
+func (k *Kitchen) dispatchCourier(courier *Courier) {
+	k.sendCourier(courier, k.courierDelay())
+}
+
+// sendCourier sends the courier that arrives at the kitchen after the delay
+func (k *Kitchen) sendCourier(courier *Courier, delay time.Duration) {
+	eta := k.clock.Now().Add(delay)
+
+	k.couriersMutex.Lock()
+	if courier.id == 0 {
+		k.lastCourierID++
+		courier.id = k.lastCourierID
+	}
+	courier.eta = eta
+	courier.timer = k.afterFunc(delay, func() {
+		k.courierArrived(courier)
+	})
+	for _, order := range courier.Orders {
+		k.dispatched[order.ID] = courier
+	}
+	k.couriersMutex.Unlock()
+
+	for _, order := range courier.Orders {
+		k.emit(Event{Type: CourierDispatched, OrderID: order.ID, CourierETA: &eta})
+	}
+}
+
+// courierArrived picks up orders for the arrived courier, a courier that arrives while the kitchen is paused comes again later
+func (k *Kitchen) courierArrived(courier *Courier) {
+	k.couriersMutex.Lock()
+	for _, order := range courier.Orders {
+		delete(k.dispatched, order.ID)
+	}
+	k.couriersMutex.Unlock()
+
+	if k.IsOnPause() {
+		k.dispatchCourier(courier)
+		return
+	}
+
+	k.couriersMutex.Lock()
+	k.couriers--
+	k.couriersMutex.Unlock()
+
+	k.logger.WithFields(k.getExtraFileds()).Infof("Courier arrive for order: %s", courier.Orders[0].ID)
+
+	courier.ArrivedAt = k.clock.Now()
+	arrival := courier.ArrivedAt.Sub(courier.RequestedAt)
+	k.count(func(stats *Stats) {
+		stats.CouriersArrived++
+		stats.CourierArrivalTotal += arrival
+		if arrival > stats.CourierArrivalMax {
+			stats.CourierArrivalMax = arrival
+		}
+	})
+	k.emit(Event{Type: CourierArrived, OrderID: courier.Orders[0].ID, CourierArrival: arrival.Seconds()})
+
+	if k.loadCourier(courier) == 0 && k.dispatch.Waits() {
+		k.couriersMutex.Lock()
+		k.waitingCouriers = append(k.waitingCouriers, courier)
+		k.couriersMutex.Unlock()
+
+		k.logger.WithFields(k.getExtraFileds()).Infof("Courier waits for an order: %s", courier.Orders[0].ID)
+		return
+	}
+
+	if !k.dispatch.Waits() {
+		for _, order := range courier.Orders {
+			if courier.pickedUp[order.ID] {
+				continue
+			}
+
+			k.count(func(stats *Stats) { stats.Missed++ })
+			k.logger.WithFields(k.getExtraFileds()).Warnf("Order not found: %s", order.ID)
+			k.emit(Event{Type: CourierMissed, OrderID: order.ID})
+		}
+	}
+
+	k.courierLeft()
+}
+
+// loadCourier picks up the orders chosen by the dispatch strategy until the courier is full, returns the number of picked up orders
+func (k *Kitchen) loadCourier(courier *Courier) int {
+	loaded := 0
+	for len(courier.pickedUp) < len(courier.Orders) && k.serveCourier(courier) {
+		loaded++
+	}
+
+	return loaded
+}
+
+// serveCourier picks up the order chosen by the dispatch strategy for the courier
+func (k *Kitchen) serveCourier(courier *Courier) bool {
+	for {
+		chosen := k.dispatch.Choose(courier, k.readyOrders())
+		if chosen == nil {
+			return false
+		}
+
+		// the chosen order may leave the shelf before the pickup, so the courier chooses again
+		if _, ok := k.pickUpOrder(chosen); !ok {
+			continue
+		}
+
+		if courier.pickedUp == nil {
+			courier.pickedUp = make(map[string]bool, len(courier.Orders))
+		}
+		courier.pickedUp[chosen.ID] = true
+
+		wait := k.clock.Now().Sub(courier.ArrivedAt)
+		k.count(func(stats *Stats) {
+			stats.CourierWaitTotal += wait
+			if wait > stats.CourierWaitMax {
+				stats.CourierWaitMax = wait
+			}
+		})
+
+		k.logger.WithFields(k.getExtraFileds()).Infof("Order recived: %s", chosen.ID)
+
+		return true
+	}
+}
+
+// serveWaitingCourier lets the courier that has waited the longest pick up orders
+func (k *Kitchen) serveWaitingCourier() {
+	k.couriersMutex.Lock()
+	if len(k.waitingCouriers) == 0 {
+		k.couriersMutex.Unlock()
+		return
+	}
+	courier := k.waitingCouriers[0]
+	k.waitingCouriers = k.waitingCouriers[1:]
+	k.couriersMutex.Unlock()
+
+	if k.loadCourier(courier) == 0 {
+		k.couriersMutex.Lock()
+		k.waitingCouriers = append([]*Courier{courier}, k.waitingCouriers...)
+		k.couriersMutex.Unlock()
+		return
+	}
+
+	k.courierLeft()
+}
+
+// cancelCourier removes the order from its courier, the courier that has no orders left is called off
+func (k *Kitchen) cancelCourier(orderID string) {
+	k.couriersMutex.Lock()
+
+	for i, order := range k.awaitingOrders {
+		if order.ID == orderID {
+			k.awaitingOrders = append(k.awaitingOrders[:i:i], k.awaitingOrders[i+1:]...)
+			break
+		}
+	}
+
+	courier, ok := k.dispatched[orderID]
+	if !ok {
+		k.couriersMutex.Unlock()
+		return
+	}
+	delete(k.dispatched, orderID)
+
+	orders := make([]*Order, 0, len(courier.Orders))
+	for _, order := range courier.Orders {
+		if order.ID != orderID {
+			orders = append(orders, order)
+		}
+	}
+	courier.Orders = orders
+
+	calledOff := len(orders) == 0 && courier.timer.Stop()
+	if calledOff {
+		k.couriers--
+		if k.fleet != nil {
+			k.busyCouriers--
+		}
+	}
+	k.couriersMutex.Unlock()
+
+	if !calledOff {
+		return
+	}
+
+	if k.fleet != nil {
+		k.assignCouriers()
+	}
+}
+
+// courierLeft sends the courier away with the orders, a courier of the fleet comes back after the delivery
+func (k *Kitchen) courierLeft() {
+	if k.fleet == nil {
+		return
+	}
+
+	k.afterFunc(k.courierDelay(), k.courierReturned)
+}
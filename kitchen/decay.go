@@ -0,0 +1,172 @@
+package kitchen
+
+import (
+	"fmt"
+	"math"
+)
+
+// DecayModel is the curve the inherent value of an order follows as the order gets older.
+// The age is measured in age units multiplied by the decay modifiers of the shelves
+type DecayModel interface {
+	// Value returns the inherent value of the order at the age, a fresh order has the value of 1
+	Value(order *Order, age float64) float64
+	// ExpiryAge returns the age the order expires at, +Inf if the order never expires
+	ExpiryAge(order *Order) float64
+}
+
+// DecaySpec names the decay model of an order and its parameters
+type DecaySpec struct {
+	// name of the model: linear, exponential, step or piecewise
+	Model string `json:"model"`
+	// value the order expires at (exponential)
+	MinValue float64 `json:"minValue,omitempty"`
+	// age units between the drops of the value (step)
+	Interval float64 `json:"interval,omitempty"`
+	// values at the given ages, the value is interpolated between the points (piecewise)
+	Points []DecayPoint `json:"points,omitempty"`
+}
+
+// DecayPoint is the value of the order at the age
+type DecayPoint struct {
+	Age   float64 `json:"age"`
+	Value float64 `json:"value"`
+}
+
+// default value the exponential model expires at
+const defaultMinValue = 0.1
+
+// NewDecayModel returns the decay model by the spec, an empty spec stands for the linear model
+func NewDecayModel(spec *DecaySpec) (DecayModel, error) {
+	if spec == nil {
+		return LinearDecayModel{}, nil
+	}
+
+	switch spec.Model {
+	case "", "linear":
+		return LinearDecayModel{}, nil
+	case "exponential":
+		minValue := spec.MinValue
+		if minValue == 0 {
+			minValue = defaultMinValue
+		}
+		if minValue <= 0 || minValue >= 1 {
+			return nil, fmt.Errorf("'minValue' of the exponential decay model must be between 0 and 1, got %v", spec.MinValue)
+		}
+
+		return ExponentialDecayModel{MinValue: minValue}, nil
+	case "step":
+		if spec.Interval <= 0 {
+			return nil, fmt.Errorf("'interval' of the step decay model must be positive, got %v", spec.Interval)
+		}
+
+		return StepDecayModel{Interval: spec.Interval}, nil
+	case "piecewise":
+		if len(spec.Points) == 0 {
+			return nil, fmt.Errorf("piecewise decay model must have 'points'")
+		}
+
+		for i, point := range spec.Points {
+			if point.Age < 0 || (i > 0 && point.Age <= spec.Points[i-1].Age) {
+				return nil, fmt.Errorf("'points' of the piecewise decay model must have increasing non-negative ages")
+			}
+		}
+
+		return PiecewiseDecayModel{Points: spec.Points}, nil
+	}
+
+	return nil, fmt.Errorf("unknown decay model '%s'", spec.Model)
+}
+
+// LinearDecayModel loses the value evenly: (shelfLife - decayRate*age)/shelfLife
+type LinearDecayModel struct{}
+
+// Value returns the linearly decayed value
+func (LinearDecayModel) Value(order *Order, age float64) float64 {
+	return (float64(order.ShelfLife) - order.DecayRate*age) / float64(order.ShelfLife)
+}
+
+// ExpiryAge returns the age the value drops to zero at
+func (LinearDecayModel) ExpiryAge(order *Order) float64 {
+	if order.DecayRate <= 0 {
+		return math.Inf(1)
+	}
+
+	return float64(order.ShelfLife) / order.DecayRate
+}
+
+// ExponentialDecayModel loses the value fast at first and slower later: exp(-decayRate*age/shelfLife).
+// The model starts decaying as fast as the linear one
+type ExponentialDecayModel struct {
+	// value the order expires at
+	MinValue float64
+}
+
+// Value returns the exponentially decayed value
+func (ExponentialDecayModel) Value(order *Order, age float64) float64 {
+	return math.Exp(-order.DecayRate * age / float64(order.ShelfLife))
+}
+
+// ExpiryAge returns the age the value drops to the min value at
+func (m ExponentialDecayModel) ExpiryAge(order *Order) float64 {
+	if order.DecayRate <= 0 {
+		return math.Inf(1)
+	}
+
+	return -math.Log(m.MinValue) * float64(order.ShelfLife) / order.DecayRate
+}
+
+// StepDecayModel keeps the value and drops it to the linearly decayed value once in the interval
+type StepDecayModel struct {
+	// age units between the drops of the value
+	Interval float64
+}
+
+// Value returns the linearly decayed value at the start of the current interval
+func (m StepDecayModel) Value(order *Order, age float64) float64 {
+	return LinearDecayModel{}.Value(order, math.Floor(age/m.Interval)*m.Interval)
+}
+
+// ExpiryAge returns the start of the interval the value drops to zero at
+func (m StepDecayModel) ExpiryAge(order *Order) float64 {
+	return math.Ceil(LinearDecayModel{}.ExpiryAge(order)/m.Interval) * m.Interval
+}
+
+// PiecewiseDecayModel interpolates the value between the points, the value is 1 at the age of zero and keeps the value of the last point after it
+type PiecewiseDecayModel struct {
+	// values at the ages sorted by age
+	Points []DecayPoint
+}
+
+// Value returns the value interpolated between the points around the age
+func (m PiecewiseDecayModel) Value(_ *Order, age float64) float64 {
+	previous := DecayPoint{Age: 0, Value: 1}
+	for _, point := range m.Points {
+		if age <= point.Age {
+			if point.Age == previous.Age {
+				return point.Value
+			}
+
+			return previous.Value + (point.Value-previous.Value)*(age-previous.Age)/(point.Age-previous.Age)
+		}
+		previous = point
+	}
+
+	return previous.Value
+}
+
+// ExpiryAge returns the age the interpolated value drops to zero at
+func (m PiecewiseDecayModel) ExpiryAge(_ *Order) float64 {
+	previous := DecayPoint{Age: 0, Value: 1}
+	for _, point := range m.Points {
+		if point.Value <= 0 {
+			if point.Age == previous.Age {
+				return point.Age
+			}
+
+			return previous.Age + (point.Age-previous.Age)*previous.Value/(previous.Value-point.Value)
+		}
+		previous = point
+	}
+
+	return math.Inf(1)
+}
@@ -0,0 +1,90 @@
+package kitchen
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	c "delivery/config"
+)
+
+func TestDecayModel(t *testing.T) {
+	order := &Order{ID: "1", ShelfLife: 100, DecayRate: 1}
+
+	cases := []struct {
+		name      string
+		spec      *DecaySpec
+		age       float64
+		want      float64
+		expiryAge float64
+	}{
+		{"default", nil, 50, 0.5, 100},
+		{"linear", &DecaySpec{Model: "linear"}, 25, 0.75, 100},
+		{"exponential", &DecaySpec{Model: "exponential", MinValue: math.Exp(-2)}, 100, math.Exp(-1), 200},
+		{"step", &DecaySpec{Model: "step", Interval: 30}, 59, 0.7, 120},
+		{"piecewise", &DecaySpec{Model: "piecewise", Points: []DecayPoint{{Age: 10, Value: 0.5}, {Age: 30, Value: -0.5}}}, 5, 0.75, 20},
+		{"piecewise_Flat", &DecaySpec{Model: "piecewise", Points: []DecayPoint{{Age: 10, Value: 0.5}}}, 50, 0.5, math.Inf(1)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			model, err := NewDecayModel(tc.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := model.Value(order, tc.age); math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("got %v want %v", got, tc.want)
+			}
+
+			if got := model.ExpiryAge(order); math.Abs(got-tc.expiryAge) > 1e-9 && got != tc.expiryAge {
+				t.Errorf("got %v want %v", got, tc.expiryAge)
+			}
+		})
+	}
+
+	t.Run("NewDecayModel_Negative", func(t *testing.T) {
+		specs := []*DecaySpec{
+			{Model: "cubic"},
+			{Model: "exponential", MinValue: 1.5},
+			{Model: "step"},
+			{Model: "piecewise"},
+			{Model: "piecewise", Points: []DecayPoint{{Age: 10, Value: 0.5}, {Age: 5, Value: 0}}},
+		}
+
+		for _, spec := range specs {
+			if _, err := NewDecayModel(spec); err == nil {
+				t.Errorf("got %v want %v", err, "error")
+			}
+		}
+	})
+
+	t.Run("Shelf_Expiry", func(t *testing.T) {
+		c.Config.Order.Age.Duration = time.Second
+
+		clock := NewVirtualClock(time.Now())
+		k := New(
+			clock,
+			map[string]*Shelf{
+				"hot": NewShelf(clock, "Hot shelf", "hot", 10, 1),
+			},
+			[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 10, 2)},
+		)
+
+		k.PlaceOrder(&Order{ID: "1", Temperature: "hot", ShelfLife: 100, DecayRate: 1, Decay: &DecaySpec{Model: "step", Interval: 30}})
+
+		clock.Advance(119 * time.Second)
+		if k.Stats().Expired != 0 {
+			t.Errorf("got %v want %v", k.Stats().Expired, 0)
+		}
+
+		clock.Advance(time.Second)
+		if k.Stats().Expired != 1 {
+			t.Errorf("got %v want %v", k.Stats().Expired, 1)
+		}
+
+		if k.PlaceOrder(&Order{ID: "2", Temperature: "hot", ShelfLife: 100, DecayRate: 1, Decay: &DecaySpec{Model: "cubic"}}) {
+			t.Errorf("got %v want %v", true, false)
+		}
+	})
+}
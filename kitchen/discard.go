@@ -0,0 +1,101 @@
+package kitchen
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// DiscardPolicy chooses the order to throw away when there is no room for a new order
+type DiscardPolicy interface {
+	// Choose returns one of the given orders at the given time, the orders are sorted by ID
+	Choose(orders []*Order, now time.Time, rnd *rand.Rand) *Order
+}
+
+// NewDiscardPolicy returns the discard policy by given name, an empty name stands for the random policy
+func NewDiscardPolicy(name string) (DiscardPolicy, error) {
+	switch name {
+	case "", "random":
+		return RandomDiscardPolicy{}, nil
+	case "lowestValue":
+		return LowestValueDiscardPolicy{}, nil
+	case "soonestToExpire":
+		return SoonestToExpireDiscardPolicy{}, nil
+	case "oldest":
+		return OldestDiscardPolicy{}, nil
+	case "highestDecayRate":
+		return HighestDecayRateDiscardPolicy{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown discard policy '%s'", name)
+}
+
+// RandomDiscardPolicy discards a random order
+type RandomDiscardPolicy struct{}
+
+// Choose returns a random order
+func (RandomDiscardPolicy) Choose(orders []*Order, _ time.Time, rnd *rand.Rand) *Order {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	return orders[rnd.Intn(len(orders))]
+}
+
+// LowestValueDiscardPolicy discards the order with the lowest inherent value
+type LowestValueDiscardPolicy struct{}
+
+// Choose returns the order with the lowest inherent value
+func (LowestValueDiscardPolicy) Choose(orders []*Order, now time.Time, _ *rand.Rand) *Order {
+	return chooseMin(orders, func(o *Order) float64 {
+		return o.GetInherentValue(now)
+	})
+}
+
+// SoonestToExpireDiscardPolicy discards the order that expires first
+type SoonestToExpireDiscardPolicy struct{}
+
+// Choose returns the order that expires first
+func (SoonestToExpireDiscardPolicy) Choose(orders []*Order, now time.Time, _ *rand.Rand) *Order {
+	return chooseMin(orders, func(o *Order) float64 {
+		return float64(o.remainingAge(now))
+	})
+}
+
+// OldestDiscardPolicy discards the oldest order
+type OldestDiscardPolicy struct{}
+
+// Choose returns the order that was placed first
+func (OldestDiscardPolicy) Choose(orders []*Order, _ time.Time, _ *rand.Rand) (result *Order) {
+	// the times are compared as they are, nanosecond timestamps do not fit into a float64 exactly
+	for _, order := range orders {
+		if result == nil || order.placedAt.Before(result.placedAt) {
+			result = order
+		}
+	}
+
+	return result
+}
+
+// HighestDecayRateDiscardPolicy discards the order with the highest decay rate
+type HighestDecayRateDiscardPolicy struct{}
+
+// Choose returns the order with the highest decay rate
+func (HighestDecayRateDiscardPolicy) Choose(orders []*Order, _ time.Time, _ *rand.Rand) *Order {
+	return chooseMin(orders, func(o *Order) float64 {
+		return -o.DecayRate
+	})
+}
+
+// chooseMin returns the first order with the minimal key
+func chooseMin(orders []*Order, key func(o *Order) float64) (result *Order) {
+	var minKey float64
+
+	for _, order := range orders {
+		if k := key(order); result == nil || k < minKey {
+			result, minKey = order, k
+		}
+	}
+
+	return result
+}
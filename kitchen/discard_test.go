@@ -0,0 +1,90 @@
+package kitchen
+
+import (
+	"testing"
+	"time"
+
+	c "delivery/config"
+)
+
+// newAgedOrder returns the order that has been on the shelf with the decay modifier for the given number of seconds
+func newAgedOrder(id string, shelfLife int, decayRate float64, decayModifier, age int, now time.Time) *Order {
+	order := &Order{ID: id, ShelfLife: shelfLife, DecayRate: decayRate}
+	order.placedAt = now.Add(-time.Duration(age) * time.Second)
+	order.enterShelf("Overflow shelf", float64(decayModifier), order.placedAt)
+
+	return order
+}
+
+func TestDiscardPolicy(t *testing.T) {
+	now := time.Now()
+	orders := []*Order{
+		newAgedOrder("1", 100, 0.5, 2, 10, now),
+		newAgedOrder("2", 20, 0.2, 2, 30, now),
+		newAgedOrder("3", 300, 0.9, 2, 5, now),
+	}
+
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"lowestValue", "2"},
+		{"soonestToExpire", "2"},
+		{"oldest", "2"},
+		{"highestDecayRate", "3"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy, err := NewDiscardPolicy(tc.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := policy.Choose(orders, now, NewRand(1))
+			if got == nil || got.ID != tc.want {
+				t.Errorf("got %v want %v", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("random", func(t *testing.T) {
+		policy, err := NewDiscardPolicy("random")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := policy.Choose(orders, now, NewRand(1)); got == nil {
+			t.Errorf("got %v want %v", got, "order")
+		}
+
+		if got := policy.Choose(nil, now, NewRand(1)); got != nil {
+			t.Errorf("got %v want %v", got, nil)
+		}
+	})
+
+	t.Run("oldest_Nanoseconds", func(t *testing.T) {
+		placedAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		newer := &Order{ID: "1", placedAt: placedAt.Add(100 * time.Nanosecond)}
+		older := &Order{ID: "2", placedAt: placedAt}
+
+		if got := (OldestDiscardPolicy{}).Choose([]*Order{newer, older}, now, NewRand(1)); got != older {
+			t.Errorf("got %v want %v", got.ID, older.ID)
+		}
+	})
+
+	t.Run("NewDiscardPolicy_Negative", func(t *testing.T) {
+		_, err := NewDiscardPolicy("newest")
+		if err == nil {
+			t.Errorf("got %v want %v", err, "error")
+		}
+	})
+
+	t.Run("ConfigNames", func(t *testing.T) {
+		for _, name := range c.DiscardPolicies {
+			if _, err := NewDiscardPolicy(name); err != nil {
+				t.Errorf("got %v want %v", err, nil)
+			}
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package kitchen
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DispatchStrategy decides which order an arrived courier picks up
+type DispatchStrategy interface {
+	// Choose returns the order the courier picks up from the ready orders or nil, ready orders are sorted by the time they were placed
+	Choose(courier *Courier, ready []Order) *Order
+	// Waits reports whether a courier that found no order waits for the next one
+	Waits() bool
+}
+
+// NewDispatchStrategy returns the dispatch strategy by name
+func NewDispatchStrategy(name string) (DispatchStrategy, error) {
+	switch name {
+	case "", "matched":
+		return MatchedDispatchStrategy{}, nil
+	case "fifo":
+		return FIFODispatchStrategy{}, nil
+	default:
+		return nil, fmt.Errorf("unknown dispatch strategy '%s'", name)
+	}
+}
+
+// MatchedDispatchStrategy sends every courier for the specific orders it was created for
+type MatchedDispatchStrategy struct{}
+
+// Choose returns the ready order the courier was sent for
+func (MatchedDispatchStrategy) Choose(courier *Courier, ready []Order) *Order {
+	for i := range ready {
+		for _, order := range courier.Orders {
+			if ready[i].ID == order.ID {
+				return &ready[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// Waits reports false, the courier leaves without the order
+func (MatchedDispatchStrategy) Waits() bool {
+	return false
+}
+
+// FIFODispatchStrategy lets an arrived courier pick up the ready order that has waited the longest
+type FIFODispatchStrategy struct{}
+
+// Choose returns the order that was placed first
+func (FIFODispatchStrategy) Choose(_ *Courier, ready []Order) *Order {
+	if len(ready) == 0 {
+		return nil
+	}
+
+	return &ready[0]
+}
+
+// Waits reports true, the courier waits for the next placed order
+func (FIFODispatchStrategy) Waits() bool {
+	return true
+}
+
+// readyOrders returns the orders on all shelves sorted by the time they were placed
+func (k *Kitchen) readyOrders() []Order {
+	var ready []Order
+	for _, shelf := range k.AllShelves() {
+		ready = append(ready, shelf.Orders()...)
+	}
+
+	sort.SliceStable(ready, func(i, j int) bool {
+		if !ready[i].placedAt.Equal(ready[j].placedAt) {
+			return ready[i].placedAt.Before(ready[j].placedAt)
+		}
+
+		return ready[i].ID < ready[j].ID
+	})
+
+	return ready
+}
@@ -0,0 +1,124 @@
+package kitchen
+
+import (
+	"testing"
+	"time"
+
+	c "delivery/config"
+)
+
+func TestDispatchStrategy(t *testing.T) {
+	c.Config.Courier.Arrive.Duration = time.Second
+	c.Config.Courier.Arrive.Min = 2
+	c.Config.Courier.Arrive.Max = 2
+
+	newKitchen := func(clock Clock, strategy DispatchStrategy) *Kitchen {
+		return New(
+			clock,
+			map[string]*Shelf{
+				"hot": NewShelf(clock, "Hot shelf", "hot", 10, 1),
+			},
+			[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 10, 2)},
+			WithDispatchStrategy(strategy),
+		)
+	}
+
+	cases := []struct {
+		name     string
+		want     string
+		waits    bool
+		foodWait float64
+	}{
+		{"matched", "2", false, 2},
+		{"fifo", "1", true, 3},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := NewVirtualClock(time.Now())
+
+			strategy, err := NewDispatchStrategy(tc.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strategy.Waits() != tc.waits {
+				t.Errorf("got %v want %v", strategy.Waits(), tc.waits)
+			}
+
+			k := newKitchen(clock, strategy)
+
+			first := &Order{ID: "1", Temperature: "hot", ShelfLife: 300, DecayRate: 0.5}
+			second := &Order{ID: "2", Temperature: "hot", ShelfLife: 300, DecayRate: 0.5}
+			k.PlaceOrder(first)
+			clock.Advance(time.Second)
+			k.PlaceOrder(second)
+			k.CreateCourier(second)
+
+			var picked string
+			k.Subscribe(func(event Event) {
+				if event.Type == OrderPickedUp {
+					picked = event.OrderID
+				}
+			})
+
+			clock.Advance(2 * time.Second)
+
+			if picked != tc.want {
+				t.Errorf("got %v want %v", picked, tc.want)
+			}
+
+			report := k.Report()
+			if report.MaxFoodWait != tc.foodWait {
+				t.Errorf("got %v want %v", report.MaxFoodWait, tc.foodWait)
+			}
+		})
+	}
+
+	t.Run("FIFO_Waits", func(t *testing.T) {
+		clock := NewVirtualClock(time.Now())
+		k := newKitchen(clock, FIFODispatchStrategy{})
+
+		order := &Order{ID: "1", Temperature: "hot", ShelfLife: 300, DecayRate: 0.5}
+		k.CreateCourier(order)
+		clock.Advance(2 * time.Second)
+
+		if k.WaitingCouriers() != 1 {
+			t.Errorf("got %v want %v", k.WaitingCouriers(), 1)
+		}
+
+		clock.Advance(3 * time.Second)
+		k.PlaceOrder(order)
+
+		if k.WaitingCouriers() != 0 {
+			t.Errorf("got %v want %v", k.WaitingCouriers(), 0)
+		}
+
+		report := k.Report()
+		if report.Delivered != 1 || report.Missed != 0 {
+			t.Errorf("got %+v want %v delivered", report.Stats, 1)
+		}
+
+		if report.MaxCourierWait != 3 {
+			t.Errorf("got %v want %v", report.MaxCourierWait, 3)
+		}
+
+		if report.MaxFoodWait != 0 {
+			t.Errorf("got %v want %v", report.MaxFoodWait, 0)
+		}
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		if _, err := NewDispatchStrategy("lifo"); err == nil {
+			t.Errorf("got %v want %v", err, "error")
+		}
+	})
+
+	t.Run("ConfigNames", func(t *testing.T) {
+		for _, name := range c.DispatchStrategies {
+			if _, err := NewDispatchStrategy(name); err != nil {
+				t.Errorf("got %v want %v", err, nil)
+			}
+		}
+	})
+}
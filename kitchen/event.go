@@ -0,0 +1,97 @@
+package kitchen
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// EventType is the kind of change in the order lifecycle
+type EventType string
+
+// Order lifecycle events
+const (
+	// the order is passed to the kitchen
+	OrderReceived EventType = "received"
+	// the order is placed on a shelf
+	OrderPlaced EventType = "placed"
+	// the kitchen has no seat for the order
+	OrderRejected EventType = "rejected"
+	// the order is moved from one shelf to another
+	OrderMoved EventType = "moved"
+	// the value of the order dropped to zero
+	OrderExpired EventType = "expired"
+	// the order is thrown away to free up a seat
+	OrderDiscarded EventType = "discarded"
+	// a courier is sent for the order
+	CourierDispatched EventType = "courierDispatched"
+	// a courier arrived at the kitchen, the event is emitted once for the first order of the courier
+	CourierArrived EventType = "courierArrived"
+	// the order is picked up by a courier
+	OrderPickedUp EventType = "pickedUp"
+	// a courier did not find the order
+	CourierMissed EventType = "missed"
+	// the order is cancelled by the customer
+	OrderCancelled EventType = "cancelled"
+)
+
+// Event is a change in the order lifecycle
+type Event struct {
+	Type    EventType `json:"type"`
+	Time    time.Time `json:"time"`
+	OrderID string    `json:"orderId"`
+	// the order itself, set for received orders only
+	Order *Order `json:"order,omitempty"`
+	// name of the shelf the order is on
+	Shelf string `json:"shelf,omitempty"`
+	// name of the shelf the order is moved from
+	FromShelf string `json:"fromShelf,omitempty"`
+	// inherent value of the order at the time of the event
+	Value float64 `json:"value"`
+	// expected arrival time of the courier
+	CourierETA *time.Time `json:"courierEta,omitempty"`
+	// seconds the courier needed to get to the kitchen, set for arrived couriers only
+	CourierArrival float64 `json:"courierArrival,omitempty"`
+}
+
+// EventHandler receives the order lifecycle events, handlers are called synchronously so they should return quickly
+type EventHandler func(event Event)
+
+// Subscribe adds the handler for the order lifecycle events
+func (k *Kitchen) Subscribe(handler EventHandler) {
+	k.handlersMutex.Lock()
+	k.handlers = append(k.handlers, handler)
+	k.handlersMutex.Unlock()
+}
+
+// emit passes the event to the subscribers
+func (k *Kitchen) emit(event Event) {
+	if event.Time.IsZero() {
+		event.Time = k.clock.Now()
+	}
+
+	k.track(event)
+	k.record(event.Type, event.OrderID, event.Time)
+
+	k.handlersMutex.Lock()
+	handlers := k.handlers
+	k.handlersMutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// WriteEvents returns the handler that writes events to w as JSON lines
+func WriteEvents(w io.Writer) EventHandler {
+	var mutex sync.Mutex
+	encoder := json.NewEncoder(w)
+
+	return func(event Event) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		encoder.Encode(event)
+	}
+}
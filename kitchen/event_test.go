@@ -0,0 +1,87 @@
+package kitchen
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	c "delivery/config"
+)
+
+func TestEvents(t *testing.T) {
+	clock := NewVirtualClock(time.Now())
+
+	t.Run("Subscribe", func(t *testing.T) {
+		c.Config.Courier.Arrive.Duration = time.Second
+		c.Config.Courier.Arrive.Min = 2
+		c.Config.Courier.Arrive.Max = 2
+
+		hotShelf := NewShelf(clock, "Hot shelf", "hot", 1, 1)
+		overflowShelf := NewShelf(clock, "Overflow shelf", "any", 1, 2)
+
+		k := New(
+			clock,
+			map[string]*Shelf{
+				"hot": hotShelf,
+			},
+			[]*Shelf{overflowShelf},
+		)
+
+		var got []EventType
+		k.Subscribe(func(event Event) {
+			got = append(got, event.Type)
+		})
+
+		first := &Order{ID: "1", Temperature: "hot", ShelfLife: 300, DecayRate: 0.5}
+		second := &Order{ID: "2", Temperature: "hot", ShelfLife: 300, DecayRate: 0.5}
+		third := &Order{ID: "3", Temperature: "hot", ShelfLife: 300, DecayRate: 0.5}
+
+		k.PlaceOrder(first)
+		k.CreateCourier(first)
+		k.PlaceOrder(second)
+		k.PlaceOrder(third)
+		k.PlaceOrder(&Order{ID: "4", Temperature: "cold"})
+
+		clock.Advance(2 * time.Second)
+
+		want := []EventType{
+			OrderReceived, OrderPlaced, CourierDispatched,
+			OrderReceived, OrderPlaced,
+			OrderReceived, OrderDiscarded, OrderPlaced,
+			OrderReceived, OrderRejected,
+			CourierArrived, OrderPickedUp, OrderMoved,
+		}
+
+		if len(got) != len(want) {
+			t.Fatalf("got %v want %v", got, want)
+		}
+
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("got %v want %v", got, want)
+				break
+			}
+		}
+	})
+
+	t.Run("WriteEvents", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		handler := WriteEvents(&buf)
+		handler(Event{Type: OrderPlaced, OrderID: "1", Shelf: "Hot shelf", Value: 1})
+		handler(Event{Type: OrderExpired, OrderID: "1", Shelf: "Hot shelf"})
+
+		decoder := json.NewDecoder(&buf)
+		for _, want := range []EventType{OrderPlaced, OrderExpired} {
+			var event Event
+			if err := decoder.Decode(&event); err != nil {
+				t.Fatal(err)
+			}
+
+			if event.Type != want {
+				t.Errorf("got %v want %v", event.Type, want)
+			}
+		}
+	})
+}
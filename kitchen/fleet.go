@@ -0,0 +1,133 @@
+package kitchen
+
+import "time"
+
+// Fleet is the limited number of couriers, orders wait for a free courier
+type Fleet struct {
+	// number of couriers working when there are no shifts
+	Size int
+	// max number of orders a courier picks up in one trip
+	Capacity int
+	// working windows of the couriers
+	Shifts []Shift
+}
+
+// Shift is the working window of the couriers, relative to the start of the kitchen
+type Shift struct {
+	Start time.Duration
+	End   time.Duration
+	// number of couriers working in the shift
+	Size int
+}
+
+// Available returns the number of couriers working after the elapsed time since the start of the kitchen
+func (f *Fleet) Available(elapsed time.Duration) int {
+	if len(f.Shifts) == 0 {
+		return f.Size
+	}
+
+	available := 0
+	for _, shift := range f.Shifts {
+		if elapsed >= shift.Start && elapsed < shift.End {
+			available += shift.Size
+		}
+	}
+
+	return available
+}
+
+// worksAfter checks if there are couriers working at the elapsed time since the start of the kitchen or later
+func (f *Fleet) worksAfter(elapsed time.Duration) bool {
+	if len(f.Shifts) == 0 {
+		return f.Size > 0
+	}
+
+	for _, shift := range f.Shifts {
+		if shift.End > elapsed && shift.Size > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// capacity returns the number of orders a courier picks up in one trip
+func (f *Fleet) capacity() int {
+	if f.Capacity < 1 {
+		return 1
+	}
+
+	return f.Capacity
+}
+
+// AwaitingOrders returns the number of orders waiting for a free courier
+func (k *Kitchen) AwaitingOrders() int {
+	k.couriersMutex.Lock()
+	defer k.couriersMutex.Unlock()
+
+	return len(k.awaitingOrders)
+}
+
+// IsStranded checks if the orders on the shelves will never be picked up: the shifts of the fleet are over and all couriers are back
+func (k *Kitchen) IsStranded() bool {
+	if k.fleet == nil {
+		return false
+	}
+
+	k.couriersMutex.Lock()
+	defer k.couriersMutex.Unlock()
+
+	return k.busyCouriers == 0 && k.couriers == 0 && !k.fleet.worksAfter(k.clock.Now().Sub(k.startedAt))
+}
+
+// scheduleShifts assigns couriers to the awaiting orders at the start of every shift
+func (k *Kitchen) scheduleShifts() {
+	for _, shift := range k.fleet.Shifts {
+		if shift.Start > 0 {
+			k.afterFunc(shift.Start, k.assignCouriers)
+		}
+	}
+}
+
+// assignCouriers sends free couriers of the fleet for the awaiting orders
+func (k *Kitchen) assignCouriers() {
+	for {
+		k.couriersMutex.Lock()
+		available := k.fleet.Available(k.clock.Now().Sub(k.startedAt))
+		if k.busyCouriers >= available || len(k.awaitingOrders) == 0 {
+			k.couriersMutex.Unlock()
+			return
+		}
+
+		var orders []*Order
+		for len(k.awaitingOrders) > 0 && len(orders) < k.fleet.capacity() {
+			order := k.awaitingOrders[0]
+			k.awaitingOrders = k.awaitingOrders[1:]
+
+			// the order could leave the kitchen while waiting for a courier
+			if _, _, ok := k.FindOrder(order.ID); ok {
+				orders = append(orders, order)
+			}
+		}
+
+		if len(orders) == 0 {
+			k.couriersMutex.Unlock()
+			continue
+		}
+
+		k.busyCouriers++
+		k.couriers++
+		k.couriersMutex.Unlock()
+
+		k.dispatchCourier(&Courier{Orders: orders, RequestedAt: k.clock.Now()})
+	}
+}
+
+// courierReturned frees the courier of the fleet after the delivery
+func (k *Kitchen) courierReturned() {
+	k.couriersMutex.Lock()
+	k.busyCouriers--
+	k.couriersMutex.Unlock()
+
+	k.assignCouriers()
+}
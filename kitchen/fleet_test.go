@@ -0,0 +1,134 @@
+package kitchen
+
+import (
+	"testing"
+	"time"
+
+	c "delivery/config"
+)
+
+func TestFleet(t *testing.T) {
+	c.Config.Courier.Arrive.Duration = time.Second
+	c.Config.Courier.Arrive.Min = 2
+	c.Config.Courier.Arrive.Max = 2
+
+	newKitchen := func(clock Clock, fleet *Fleet) *Kitchen {
+		return New(
+			clock,
+			map[string]*Shelf{
+				"hot": NewShelf(clock, "Hot shelf", "hot", 10, 1),
+			},
+			[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 10, 2)},
+			WithFleet(fleet),
+		)
+	}
+
+	t.Run("Available", func(t *testing.T) {
+		fleet := &Fleet{
+			Size: 3,
+			Shifts: []Shift{
+				{Start: 0, End: time.Hour, Size: 2},
+				{Start: 30 * time.Minute, End: 2 * time.Hour, Size: 1},
+			},
+		}
+
+		cases := []struct {
+			elapsed time.Duration
+			want    int
+		}{
+			{0, 2},
+			{45 * time.Minute, 3},
+			{time.Hour, 1},
+			{3 * time.Hour, 0},
+		}
+		for _, tc := range cases {
+			if got := fleet.Available(tc.elapsed); got != tc.want {
+				t.Errorf("%v: got %v want %v", tc.elapsed, got, tc.want)
+			}
+		}
+
+		if got := (&Fleet{Size: 3}).Available(time.Hour); got != 3 {
+			t.Errorf("got %v want %v", got, 3)
+		}
+	})
+
+	t.Run("WaitForFreeCourier", func(t *testing.T) {
+		clock := NewVirtualClock(time.Now())
+		k := newKitchen(clock, &Fleet{Size: 1, Capacity: 1})
+
+		for _, id := range []string{"1", "2"} {
+			order := &Order{ID: id, Temperature: "hot", ShelfLife: 300, DecayRate: 0.5}
+			k.PlaceOrder(order)
+			k.CreateCourier(order)
+		}
+
+		if k.PendingCouriers() != 1 || k.AwaitingOrders() != 1 {
+			t.Errorf("got %v want %v", k.AwaitingOrders(), 1)
+		}
+
+		// the courier picks up the first order, delivers it and comes back for the second one
+		clock.Advance(4 * time.Second)
+		if k.PendingCouriers() != 1 || k.AwaitingOrders() != 0 {
+			t.Errorf("got %v want %v", k.PendingCouriers(), 1)
+		}
+
+		clock.Advance(2 * time.Second)
+
+		report := k.Report()
+		if report.Delivered != 2 {
+			t.Errorf("got %v want %v", report.Delivered, 2)
+		}
+
+		if report.MaxFoodWait != 6 {
+			t.Errorf("got %v want %v", report.MaxFoodWait, 6)
+		}
+	})
+
+	t.Run("Capacity", func(t *testing.T) {
+		clock := NewVirtualClock(time.Now())
+		k := newKitchen(clock, &Fleet{Size: 1, Capacity: 2})
+
+		for _, id := range []string{"1", "2", "3"} {
+			order := &Order{ID: id, Temperature: "hot", ShelfLife: 300, DecayRate: 0.5}
+			k.PlaceOrder(order)
+			k.CreateCourier(order)
+		}
+
+		if k.AwaitingOrders() != 2 {
+			t.Errorf("got %v want %v", k.AwaitingOrders(), 2)
+		}
+
+		// the courier comes back and takes both awaiting orders in one trip
+		clock.Advance(6 * time.Second)
+
+		report := k.Report()
+		if report.Delivered != 3 || report.CouriersArrived != 2 {
+			t.Errorf("got %+v want %v delivered", report.Stats, 3)
+		}
+	})
+
+	t.Run("Shifts", func(t *testing.T) {
+		clock := NewVirtualClock(time.Now())
+		k := newKitchen(clock, &Fleet{
+			Capacity: 2,
+			Shifts:   []Shift{{Start: 10 * time.Second, End: time.Minute, Size: 1}},
+		})
+
+		for _, id := range []string{"1", "2"} {
+			order := &Order{ID: id, Temperature: "hot", ShelfLife: 300, DecayRate: 0.5}
+			k.PlaceOrder(order)
+			k.CreateCourier(order)
+		}
+
+		if k.AwaitingOrders() != 2 {
+			t.Errorf("got %v want %v", k.AwaitingOrders(), 2)
+		}
+
+		clock.Advance(12 * time.Second)
+
+		report := k.Report()
+		if report.Delivered != 2 || report.CouriersArrived != 1 {
+			t.Errorf("got %+v want %v delivered", report.Stats, 2)
+		}
+	})
+}
@@ -0,0 +1,430 @@
+package kitchen
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// name of the snapshot file in the journal directory
+	snapshotFile = "snapshot.json"
+	// prefix of the journal segment files, the segment is named by the sequence number of its first entry
+	segmentPrefix = "journal-"
+	segmentSuffix = ".jsonl"
+)
+
+// Journal entries of the kitchen that are not order lifecycle events
+const (
+	journalPaused   EventType = "paused"
+	journalUnpaused EventType = "unpaused"
+)
+
+// Journal is the append-only log of the kitchen mutations, kept in a directory together with the snapshot of the kitchen state.
+// Every order lifecycle event is written with the order and its courier as they are after the event, so the entries can be replayed
+// on top of the snapshot. A snapshot starts a new segment of the log and removes the segments it covers
+type Journal struct {
+	dir     string
+	file    *os.File
+	encoder *json.Encoder
+	// sequence number of the last entry
+	seq   int64
+	mutex sync.Mutex
+}
+
+// journalEntry is the mutation of the kitchen
+type journalEntry struct {
+	Seq     int64     `json:"seq"`
+	Type    EventType `json:"type"`
+	Time    time.Time `json:"time"`
+	OrderID string    `json:"orderId,omitempty"`
+	// the order after the mutation, nil if the order is not on a shelf
+	Order *SavedOrder `json:"order,omitempty"`
+	// the courier on its way for the order after the mutation
+	Courier *SavedCourier `json:"courier,omitempty"`
+	Stats   SavedStats    `json:"stats"`
+	// time the kitchen was started
+	StartedAt time.Time `json:"startedAt"`
+	// number of the orders taken from the orders file
+	Cursor int `json:"cursor"`
+}
+
+// snapshot is the state of the kitchen that covers the journal entries up to the sequence number
+type snapshot struct {
+	Seq   int64 `json:"seq"`
+	State State `json:"state"`
+}
+
+// OpenJournal opens the journal in the directory, the directory is created if it does not exist.
+// Returns the state saved in the journal or nil if the journal is empty
+func OpenJournal(dir string) (*Journal, *State, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, err
+	}
+
+	j := &Journal{dir: dir}
+
+	state, err := j.load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := j.startSegment(); err != nil {
+		return nil, nil, err
+	}
+
+	return j, state, nil
+}
+
+// Close closes the current segment of the journal
+func (j *Journal) Close() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.file.Close()
+}
+
+// Snapshot saves the state of the kitchen and removes the journal segments the snapshot covers
+func (j *Journal) Snapshot(k *Kitchen) error {
+	j.mutex.Lock()
+	seq := j.seq
+	err := j.startSegment()
+	j.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// entries written while the state is taken are replayed on top of it, replaying an entry twice gives the same state
+	data, err := json.Marshal(snapshot{Seq: seq, State: k.State()})
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(j.dir, snapshotFile)
+	if err := writeFile(path+".tmp", data); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+
+	segments, err := j.segments()
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range segments {
+		if segment.first <= seq {
+			if err := os.Remove(segment.path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// write appends the entry made by the kitchen, the entry is made under the lock so the entries follow the order of the mutations
+func (j *Journal) write(entry func() journalEntry) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	e := entry()
+	e.Seq = j.seq + 1
+	if err := j.encoder.Encode(e); err != nil {
+		return err
+	}
+	j.seq = e.Seq
+
+	return nil
+}
+
+// startSegment closes the current segment and starts the new one with the next entry, the caller must hold the mutex
+func (j *Journal) startSegment() error {
+	path := filepath.Join(j.dir, fmt.Sprintf("%s%020d%s", segmentPrefix, j.seq+1, segmentSuffix))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	if j.file != nil {
+		j.file.Close()
+	}
+	j.file = file
+	j.encoder = json.NewEncoder(file)
+
+	return nil
+}
+
+// load replays the journal segments on top of the snapshot
+func (j *Journal) load() (*State, error) {
+	var state *State
+
+	data, err := ioutil.ReadFile(filepath.Join(j.dir, snapshotFile))
+	switch {
+	case err == nil:
+		var s snapshot
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("invalid snapshot: %s", err)
+		}
+		state = &s.State
+		j.seq = s.Seq
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+
+	segments, err := j.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, segment := range segments {
+		entries, size, err := readSegment(segment.path)
+		if err != nil {
+			return nil, err
+		}
+
+		// the entry cut off by a crash is truncated, otherwise the entries appended to the segment after a restart could not be read
+		info, err := os.Stat(segment.path)
+		if err != nil {
+			return nil, err
+		}
+		if info.Size() > size {
+			if err := os.Truncate(segment.path, size); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, entry := range entries {
+			if entry.Seq <= j.seq {
+				continue
+			}
+
+			if state == nil {
+				state = &State{Orders: []SavedOrder{}, Couriers: []SavedCourier{}}
+			}
+			state.apply(entry)
+			j.seq = entry.Seq
+		}
+	}
+
+	return state, nil
+}
+
+// segment is the file of the journal
+type segment struct {
+	path string
+	// sequence number of the first entry
+	first int64
+}
+
+// segments returns the segments of the journal in order
+func (j *Journal) segments() ([]segment, error) {
+	files, err := ioutil.ReadDir(j.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []segment
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+
+		var first int64
+		if _, err := fmt.Sscanf(strings.TrimPrefix(name, segmentPrefix), "%d", &first); err != nil {
+			continue
+		}
+
+		result = append(result, segment{path: filepath.Join(j.dir, name), first: first})
+	}
+
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].first < result[b].first
+	})
+
+	return result, nil
+}
+
+// readSegment reads the entries of the segment and returns the size of the complete entries,
+// the entry cut off by a crash at the end of the segment is skipped
+func readSegment(path string) ([]journalEntry, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	var entries []journalEntry
+	var size int64
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// every entry is written with the newline, the line without it was cut off
+			return entries, size, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		var entry journalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, 0, fmt.Errorf("invalid journal entry in %s: %s", filepath.Base(path), err)
+		}
+
+		entries = append(entries, entry)
+		size += int64(len(line))
+	}
+}
+
+// writeFile writes the data to the file and flushes it to the disk
+func writeFile(path string, data []byte) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// apply replays the journal entry on the state
+func (s *State) apply(entry journalEntry) {
+	s.Time = entry.Time
+	s.StartedAt = entry.StartedAt
+	s.Stats = entry.Stats
+	s.Cursor = entry.Cursor
+
+	switch entry.Type {
+	case journalPaused:
+		s.Paused = true
+		for i := range s.Orders {
+			stints := s.Orders[i].Stints
+			if n := len(stints); n > 0 && stints[n-1].To.IsZero() {
+				stints[n-1].To = entry.Time
+			}
+		}
+		return
+	case journalUnpaused:
+		s.Paused = false
+		for i := range s.Orders {
+			stints := s.Orders[i].Stints
+			if n := len(stints); n > 0 && !stints[n-1].To.IsZero() {
+				last := stints[n-1]
+				s.Orders[i].Stints = append(stints, Stint{Shelf: last.Shelf, DecayModifier: last.DecayModifier, From: entry.Time})
+			}
+		}
+		return
+	}
+
+	s.applyOrder(entry)
+	s.applyCourier(entry)
+}
+
+// applyOrder replaces the order of the entry, the order that has left the shelves is removed
+func (s *State) applyOrder(entry journalEntry) {
+	for i, order := range s.Orders {
+		if order.Order.ID != entry.OrderID {
+			continue
+		}
+
+		if entry.Order != nil {
+			s.Orders[i] = *entry.Order
+		} else {
+			s.Orders = append(s.Orders[:i:i], s.Orders[i+1:]...)
+		}
+		return
+	}
+
+	if entry.Order != nil {
+		s.Orders = append(s.Orders, *entry.Order)
+	}
+}
+
+// applyCourier replaces the courier of the entry, the arrived courier is removed and the order without a courier is taken off its former courier
+func (s *State) applyCourier(entry journalEntry) {
+	if entry.Courier != nil {
+		for i, courier := range s.Couriers {
+			if courier.ID == entry.Courier.ID {
+				s.Couriers[i] = *entry.Courier
+				return
+			}
+		}
+
+		s.Couriers = append(s.Couriers, *entry.Courier)
+		return
+	}
+
+	for i, courier := range s.Couriers {
+		orders := make([]string, 0, len(courier.Orders))
+		for _, orderID := range courier.Orders {
+			if orderID != entry.OrderID {
+				orders = append(orders, orderID)
+			}
+		}
+
+		if len(orders) == len(courier.Orders) {
+			continue
+		}
+
+		if entry.Type == CourierArrived || len(orders) == 0 {
+			s.Couriers = append(s.Couriers[:i:i], s.Couriers[i+1:]...)
+		} else {
+			s.Couriers[i].Orders = orders
+		}
+		return
+	}
+}
+
+// record writes the mutation of the order to the journal of the kitchen
+func (k *Kitchen) record(eventType EventType, orderID string, now time.Time) {
+	if k.journal == nil {
+		return
+	}
+
+	err := k.journal.write(func() journalEntry {
+		entry := journalEntry{Type: eventType, Time: now, OrderID: orderID, StartedAt: k.startedAt, Cursor: k.Cursor()}
+
+		if orderID != "" {
+			if order, shelf, ok := k.FindOrder(orderID); ok {
+				saved := saveOrder(order, shelf)
+				entry.Order = &saved
+			}
+
+			k.couriersMutex.Lock()
+			if courier, ok := k.dispatched[orderID]; ok {
+				saved := saveCourier(courier)
+				entry.Courier = &saved
+			}
+			k.couriersMutex.Unlock()
+		}
+
+		stats := k.Stats()
+		stats.OnShelves = 0
+		entry.Stats = saveStats(stats)
+
+		return entry
+	})
+	if err != nil {
+		k.logger.WithFields(k.getExtraFileds()).Errorf("Cannot write journal: %s", err)
+	}
+}
@@ -0,0 +1,174 @@
+package kitchen
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	journal, saved, err := OpenJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if saved != nil {
+		t.Errorf("got %v want %v", saved, nil)
+	}
+
+	clock := NewVirtualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	k := New(
+		clock,
+		map[string]*Shelf{
+			"hot": NewShelf(clock, "Hot shelf", "hot", 1, 1),
+		},
+		[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 10, 2)},
+		WithCourierArrival(CourierArrival{Min: 4, Max: 4, Unit: time.Second}),
+		WithAgeUnit(time.Second),
+		WithJournal(journal),
+	)
+
+	for _, order := range []*Order{
+		{ID: "1", Temperature: "hot", ShelfLife: 100, DecayRate: 1},
+		{ID: "2", Temperature: "hot", ShelfLife: 100, DecayRate: 1},
+		{ID: "3", Temperature: "hot", ShelfLife: 100, DecayRate: 1},
+		{ID: "4", Temperature: "hot", ShelfLife: 100, DecayRate: 1},
+	} {
+		k.AdvanceCursor()
+		k.PlaceOrder(order)
+		k.CreateCourier(order)
+		clock.Advance(time.Second)
+	}
+	k.CancelOrder("2")
+
+	// the order 1 is picked up, the order 3 moves to the hot shelf
+	clock.Advance(time.Second)
+	k.Pause()
+	clock.Advance(time.Second)
+
+	reopen := func(t *testing.T) *State {
+		t.Helper()
+
+		reopened, saved, err := OpenJournal(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reopened.Close()
+
+		if saved == nil {
+			t.Fatalf("got %v want %v", saved, "state")
+		}
+
+		return saved
+	}
+
+	assertState := func(t *testing.T, got *State) {
+		t.Helper()
+
+		want := k.State()
+		// the state is compared as it is saved, the time of the state is the time of the last entry
+		want.Time = got.Time
+
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		if string(gotJSON) != string(wantJSON) {
+			t.Errorf("got %s want %s", gotJSON, wantJSON)
+		}
+	}
+
+	t.Run("Replay", func(t *testing.T) {
+		assertState(t, reopen(t))
+	})
+
+	t.Run("Snapshot", func(t *testing.T) {
+		if err := journal.Snapshot(k); err != nil {
+			t.Fatal(err)
+		}
+
+		k.Unpause()
+
+		segments, err := journal.segments()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(segments) != 1 {
+			t.Errorf("got %v want %v", len(segments), 1)
+		}
+
+		assertState(t, reopen(t))
+	})
+
+	journal.Close()
+
+	t.Run("Replay_CutOffEntry", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "journal")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		start := func(t *testing.T) (*Journal, *Kitchen) {
+			t.Helper()
+
+			journal, saved, err := OpenJournal(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			clock := NewVirtualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+			k := New(
+				clock,
+				map[string]*Shelf{
+					"hot": NewShelf(clock, "Hot shelf", "hot", 10, 1),
+				},
+				[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 10, 2)},
+				WithAgeUnit(time.Second),
+				WithJournal(journal),
+			)
+			if saved != nil {
+				if err := k.Restore(*saved); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			return journal, k
+		}
+
+		journal, k := start(t)
+		k.PlaceOrder(&Order{ID: "1", Temperature: "hot", ShelfLife: 100, DecayRate: 1})
+		if err := journal.Snapshot(k); err != nil {
+			t.Fatal(err)
+		}
+
+		// the crash cuts off the first entry of the new segment
+		if _, err := journal.file.WriteString(`{"seq":`); err != nil {
+			t.Fatal(err)
+		}
+		journal.Close()
+		k.Close()
+
+		journal, k = start(t)
+		k.PlaceOrder(&Order{ID: "2", Temperature: "hot", ShelfLife: 100, DecayRate: 1})
+		journal.Close()
+		k.Close()
+
+		journal, k = start(t)
+		defer journal.Close()
+		defer k.Close()
+
+		for _, orderID := range []string{"1", "2"} {
+			if _, _, ok := k.FindOrder(orderID); !ok {
+				t.Errorf("got %v want %v", ok, true)
+			}
+		}
+	})
+}
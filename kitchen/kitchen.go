@@ -2,82 +2,238 @@
 package kitchen
 
 import (
-	c "delivery/config"
-	"io/ioutil"
+	"context"
+	"errors"
+	"fmt"
 	"math/rand"
-	"os"
+	"sort"
 	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// Reasons the order is not placed
+var (
+	// ErrClosed is returned for the order that comes after the kitchen is closed
+	ErrClosed = errors.New("kitchen is closed")
+	// ErrDuplicateOrder is returned for the order with the ID of an order the kitchen has already received
+	ErrDuplicateOrder = errors.New("order already exists")
+	// ErrNoSeats is returned when there are no seats for the order and no order can be discarded for it
+	ErrNoSeats = errors.New("there are no available seats for the order")
+)
+
 // Kitchen -
 type Kitchen struct {
 	// shelves by temperature
 	Shelves map[string]*Shelf
-	// shelf for orders with any temperature
-	OverflowShelf *Shelf
-
-	paused bool
-	logger *log.Entry
-	mutex  sync.Mutex
+	// fallback shelves in priority order
+	OverflowShelves []*Shelf
+
+	ctx             context.Context
+	cancel          context.CancelFunc
+	clock           Clock
+	rand            *rand.Rand
+	dispatch        DispatchStrategy
+	fleet           *Fleet
+	compatibility   Compatibility
+	courierArrival  *CourierArrival
+	ageUnit         time.Duration
+	startedAt       time.Time
+	paused          bool
+	stats           Stats
+	couriers        int
+	waitingCouriers []*Courier
+	awaitingOrders  []*Order
+	dispatched      map[string]*Courier
+	busyCouriers    int
+	lastCourierID   int
+	cursor          int
+	handlers        []EventHandler
+	journal         *Journal
+	records         map[string]*orderRecord
+	logger          *log.Entry
+	mutex           sync.Mutex
+	statsMutex      sync.Mutex
+	handlersMutex   sync.Mutex
+	recordsMutex    sync.Mutex
+	couriersMutex   sync.Mutex
+	pausedMutex     sync.Mutex
+	cursorMutex     sync.Mutex
+	timers          map[int]Timer
+	lastTimerID     int
+	timersMutex     sync.Mutex
 }
 
 // New creates new kitchen by given parameters
-func New(shelves map[string]*Shelf, overflowShelf *Shelf) *Kitchen {
-	capacity := overflowShelf.Capacity
+func New(clock Clock, shelves map[string]*Shelf, overflowShelves []*Shelf, options ...Option) *Kitchen {
+	capacity := 0
+	for _, s := range overflowShelves {
+		capacity += s.Capacity
+	}
 	for _, s := range shelves {
 		capacity += s.Capacity
 	}
 
-	logger := log.New()
-
-	if os.Getenv("GO_ENV") == "testing" {
-		logger.SetOutput(ioutil.Discard)
-	}
+	logger := newLogger()
 
 	k := &Kitchen{
-		Shelves:       shelves,
-		OverflowShelf: overflowShelf,
-		paused:        false,
+		Shelves:         shelves,
+		OverflowShelves: overflowShelves,
+		ctx:             context.Background(),
+		clock:           clock,
+		rand:            NewRand(time.Now().UnixNano()),
+		dispatch:        MatchedDispatchStrategy{},
+		startedAt:       clock.Now(),
+		paused:          false,
+		records:         make(map[string]*orderRecord),
+		dispatched:      make(map[string]*Courier),
+		timers:          make(map[int]Timer),
 		logger: logger.WithFields(log.Fields{
 			"source":   "kitchen",
 			"capacity": capacity,
 		}),
 	}
 
+	for _, s := range shelves {
+		s.onVacancy = k.FillVacancies
+		s.emit = k.emit
+	}
+	for _, s := range overflowShelves {
+		s.emit = k.emit
+	}
+
+	for _, option := range options {
+		option(k)
+	}
+
+	parent := k.ctx
+	k.ctx, k.cancel = context.WithCancel(parent)
+	if parent.Done() != nil {
+		go func() {
+			<-k.ctx.Done()
+			k.Close()
+		}()
+	}
+
+	if k.fleet != nil {
+		k.scheduleShifts()
+	}
+
 	return k
 }
 
+// Close stops the kitchen: the shelves stop aging the orders, the couriers are called off and new orders are not accepted
+func (k *Kitchen) Close() {
+	k.cancel()
+	k.stopTimers()
+
+	for _, s := range k.AllShelves() {
+		s.Close()
+	}
+
+	k.couriersMutex.Lock()
+	k.waitingCouriers = nil
+	k.awaitingOrders = nil
+	k.dispatched = make(map[string]*Courier)
+	k.couriers = 0
+	k.couriersMutex.Unlock()
+}
+
+// Now returns the current time of the kitchen clock
+func (k *Kitchen) Now() time.Time {
+	return k.clock.Now()
+}
+
+// Done returns the channel that is closed when the kitchen is closed
+func (k *Kitchen) Done() <-chan struct{} {
+	return k.ctx.Done()
+}
+
 // PlaceOrder adds order to the shelf
-func (k *Kitchen) PlaceOrder(order *Order) (result bool) {
+func (k *Kitchen) PlaceOrder(order *Order) bool {
+	return k.Place(order) == nil
+}
+
+// Place adds the order to the shelf and returns why the order is not placed: ErrClosed, ErrDuplicateOrder, ErrNoSeats or the problem of the order.
+// The ID of the order is checked against every order the kitchen has received
+func (k *Kitchen) Place(order *Order) error {
+	if k.ctx.Err() != nil {
+		k.logger.WithFields(k.getExtraFileds()).Warnf("Kitchen is closed, order is not accepted: %s", order.ID)
+		return ErrClosed
+	}
+
+	model, err := NewDecayModel(order.Decay)
+	if err != nil {
+		k.logger.WithFields(k.getExtraFileds()).Warnf("Order %s is not accepted: %s", order.ID, err)
+		return err
+	}
+	order.decayModel = model
+
+	if !k.receive(order.ID) {
+		k.logger.WithFields(k.getExtraFileds()).Warnf("Order already exists, order is not accepted: %s", order.ID)
+		return ErrDuplicateOrder
+	}
+
+	order.placedAt = k.clock.Now()
+	if k.ageUnit > 0 {
+		order.ageUnit = k.ageUnit
+	}
+	k.emit(Event{Type: OrderReceived, OrderID: order.ID, Order: order, Value: order.GetInherentValue(k.clock.Now())})
+
+	var placedOn *Shelf
+	var result bool
+	defer func() {
+		k.count(func(stats *Stats) {
+			stats.Received++
+			if result {
+				stats.Placed++
+			} else {
+				stats.Rejected++
+			}
+		})
+
+		if result {
+			k.emit(Event{Type: OrderPlaced, OrderID: order.ID, Shelf: placedOn.Name, Value: order.GetInherentValue(k.clock.Now())})
+			k.serveWaitingCourier()
+		} else {
+			k.emit(Event{Type: OrderRejected, OrderID: order.ID, Value: order.GetInherentValue(k.clock.Now())})
+		}
+	}()
+
 	shelf, ok := k.Shelves[order.Temperature]
-	if !ok {
+	if !ok && len(k.compatibility[order.Temperature]) == 0 {
 		k.logger.WithFields(k.getExtraFileds()).Warnf("There is no shelf with temprature '%s' for order with ID %s", order.Temperature, order.ID)
-		return false
+		return fmt.Errorf("there is no shelf with temperature '%s'", order.Temperature)
 	}
 
-	result = shelf.AddOrder(order)
-	if !result {
-		result = k.OverflowShelf.AddOrder(order)
+	if ok && shelf.AddOrder(order) {
+		placedOn, result = shelf, true
+		return nil
+	}
+
+	if compatible, ok := k.placeOnCompatibleShelf(order); ok {
+		placedOn, result = compatible, true
+		return nil
 	}
 
+	placedOn, result = k.placeOnOverflowShelf(order)
 	if !result {
-		result = k.RotateOrdersFromOverflowShelve(order)
+		placedOn, result = k.rotateOrdersFromOverflowShelves(order)
 		if !result {
 			k.logger.WithFields(k.getExtraFileds()).Warn("There are no available seats on the kitchen")
+			return ErrNoSeats
 		}
 	}
 
-	return result
+	return nil
 }
 
 // GetAvailableShelves returns available shelves that have empty seats
 func (k *Kitchen) GetAvailableShelves() []*Shelf {
 	var result []*Shelf
 
-	for _, s := range k.Shelves {
+	for _, s := range k.sortedShelves() {
 		if s.HasEmptySeats() {
 			result = append(result, s)
 		}
@@ -86,11 +242,11 @@ func (k *Kitchen) GetAvailableShelves() []*Shelf {
 	return result
 }
 
-// IsEmpty checks if the all shelves are empty
+// IsEmpty checks if the all shelves are empty, the overflow shelves included
 func (k *Kitchen) IsEmpty() bool {
 	result := true
 
-	for _, s := range k.Shelves {
+	for _, s := range k.AllShelves() {
 		if !result {
 			break
 		}
@@ -100,98 +256,280 @@ func (k *Kitchen) IsEmpty() bool {
 	return result
 }
 
-// RotateOrdersFromOverflowShelve frees up space on a shelf by moving an order to another shelf or deletes a randomly selected order if there are no free spaces on other shelves
-func (k *Kitchen) RotateOrdersFromOverflowShelve(order *Order) (result bool) {
+// RotateOrdersFromOverflowShelve frees up a seat for the order on the overflow shelves that accept it, walking them in priority order.
+// An order is moved from the overflow shelf to a shelf with an empty seat for its temperature or a compatible shelf, if there are no free seats on other shelves
+// the first overflow shelf discards an order chosen by its policy
+func (k *Kitchen) RotateOrdersFromOverflowShelve(order *Order) bool {
+	_, ok := k.rotateOrdersFromOverflowShelves(order)
+
+	return ok
+}
+
+// rotateOrdersFromOverflowShelves frees up a seat for the order and returns the overflow shelf the order is placed on
+func (k *Kitchen) rotateOrdersFromOverflowShelves(order *Order) (*Shelf, bool) {
 	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	accepting := k.overflowShelvesFor(order.Temperature)
+	if len(accepting) == 0 {
+		return nil, false
+	}
+
+	for _, overflowShelf := range accepting {
+		if k.moveOrderFromOverflowShelf(overflowShelf) && overflowShelf.addOrder(order, k.decayMultiplier(order.Temperature, overflowShelf)) {
+			return overflowShelf, true
+		}
+	}
 
-	availableShelves := k.GetAvailableShelves()
+	overflowShelf := accepting[0]
+	overflowShelf.DiscardOrder(k.rand)
 
-	if len(availableShelves) > 0 {
-		for _, availableShelf := range availableShelves {
-			orderToMove := k.OverflowShelf.FindOrderByTemp(availableShelf.Temperature)
-			if orderToMove != nil {
-				availableShelf.AddOrder(orderToMove)
-				k.OverflowShelf.DeleteOrder(orderToMove.ID)
+	return overflowShelf, overflowShelf.addOrder(order, k.decayMultiplier(order.Temperature, overflowShelf))
+}
 
-				result = k.OverflowShelf.AddOrder(order)
-				break
-			}
+// FillVacancies moves orders to the shelves that have empty seats: penalised orders on compatible shelves go back to the shelves
+// of their temperatures first, then the orders on the overflow shelves move to the shelves of their temperatures or to compatible shelves
+func (k *Kitchen) FillVacancies() {
+	k.mutex.Lock()
+
+	for _, shelf := range k.sortedShelves() {
+		for k.moveOrderToOwnShelf(shelf) {
+			continue
+		}
+	}
+
+	for _, overflowShelf := range k.OverflowShelves {
+		for k.moveOrderFromOverflowShelf(overflowShelf) {
+			continue
 		}
-	} else {
-		k.OverflowShelf.DeleteRandomOrder()
-		result = k.OverflowShelf.AddOrder(order)
 	}
 
 	k.mutex.Unlock()
+}
+
+// placeOnOverflowShelf adds the order to the first overflow shelf that accepts its temperature and has an empty seat
+func (k *Kitchen) placeOnOverflowShelf(order *Order) (*Shelf, bool) {
+	for _, overflowShelf := range k.overflowShelvesFor(order.Temperature) {
+		if overflowShelf.addOrder(order, k.decayMultiplier(order.Temperature, overflowShelf)) {
+			return overflowShelf, true
+		}
+	}
+
+	return nil, false
+}
+
+// overflowShelvesFor returns the overflow shelves that accept the temperature in priority order
+func (k *Kitchen) overflowShelvesFor(temp string) []*Shelf {
+	var result []*Shelf
+	for _, overflowShelf := range k.OverflowShelves {
+		if overflowShelf.Accepts(temp) {
+			result = append(result, overflowShelf)
+		}
+	}
 
 	return result
 }
 
-// CreateCourier creates a courier for the order
-func (k *Kitchen) CreateCourier(order *Order) {
-	for {
-		rand.Seed(time.Now().UnixNano())
-		randValue := rand.Intn(c.Config.Courier.Arrive.Max-c.Config.Courier.Arrive.Min+1) + c.Config.Courier.Arrive.Max
-		time.Sleep(time.Duration(randValue) * c.Config.Courier.Arrive.Duration)
+// isOverflowShelf checks if the shelf is one of the overflow shelves
+func (k *Kitchen) isOverflowShelf(shelf *Shelf) bool {
+	for _, overflowShelf := range k.OverflowShelves {
+		if overflowShelf == shelf {
+			return true
+		}
+	}
 
-		if k.paused {
-			continue
+	return false
+}
+
+// moveOrderFromOverflowShelf moves the order on the overflow shelf that is the closest to expiring to a shelf with an empty seat for its temperature,
+// when there is none the order may move to a compatible shelf on which it decays slower. The caller must hold the mutex
+func (k *Kitchen) moveOrderFromOverflowShelf(overflowShelf *Shelf) bool {
+	temps := make([]string, 0, len(k.Shelves))
+	for _, s := range k.GetAvailableShelves() {
+		temps = append(temps, s.Temperature)
+	}
+
+	if len(temps) == 0 {
+		return false
+	}
+
+	orderToMove := overflowShelf.FindOrderByTemp(temps...)
+	if orderToMove == nil {
+		return k.moveOrderToCompatibleShelf(overflowShelf)
+	}
+
+	return k.moveOrder(orderToMove.ID, overflowShelf, k.Shelves[orderToMove.Temperature])
+}
+
+// moveOrder moves the order from one shelf to another, the order stays on its shelf when the other one is full. The caller must hold the mutex
+func (k *Kitchen) moveOrder(orderID string, from, to *Shelf) bool {
+	order, ok := from.WithdrawOrder(orderID)
+	if !ok {
+		return false
+	}
+
+	if !to.addOrder(order, k.decayMultiplier(order.Temperature, to)) {
+		from.addOrder(order, k.decayMultiplier(order.Temperature, from))
+		return false
+	}
+
+	k.logger.WithFields(k.getExtraFileds()).Infof("Order moved to %s: %s", to.Name, order.ID)
+	k.emit(Event{
+		Type:      OrderMoved,
+		OrderID:   order.ID,
+		Shelf:     to.Name,
+		FromShelf: from.Name,
+		Value:     order.GetInherentValue(k.clock.Now()),
+	})
+
+	return true
+}
+
+// PickUpOrder picks up an order from a shelf
+func (k *Kitchen) PickUpOrder(order *Order) bool {
+	_, ok := k.pickUpOrder(order)
+
+	return ok
+}
+
+// pickUpOrder withdraws the order from a shelf and returns the withdrawn order
+func (k *Kitchen) pickUpOrder(order *Order) (*Order, bool) {
+	var shelf *Shelf
+	var withdrawn *Order
+	for _, s := range k.AllShelves() {
+		if order, ok := s.WithdrawOrder(order.ID); ok {
+			shelf, withdrawn = s, order
+			break
 		}
+	}
 
-		k.logger.WithFields(k.getExtraFileds()).Infof("Courier arrive for order: %s", order.ID)
+	if withdrawn == nil {
+		return nil, false
+	}
 
-		ok := k.PickUpOrder(order)
-		if ok {
-			k.logger.WithFields(k.getExtraFileds()).Infof("Order recived: %s", order.ID)
-		} else {
-			k.logger.WithFields(k.getExtraFileds()).Warnf("Order not found: %s", order.ID)
+	now := k.clock.Now()
+	value := withdrawn.GetInherentValue(now)
+	foodWait := now.Sub(withdrawn.placedAt)
+	k.count(func(stats *Stats) {
+		stats.Delivered++
+		stats.DeliveredValue += value
+		stats.FoodWaitTotal += foodWait
+		if foodWait > stats.FoodWaitMax {
+			stats.FoodWaitMax = foodWait
 		}
+	})
+	k.emit(Event{Type: OrderPickedUp, OrderID: withdrawn.ID, Shelf: shelf.Name, Value: value})
 
-		break
+	if !k.isOverflowShelf(shelf) {
+		k.FillVacancies()
 	}
+
+	return withdrawn, true
 }
 
-// PickUpOrder зicks up an order from a shelf
-func (k *Kitchen) PickUpOrder(order *Order) (ok bool) {
-	_, ok = k.Shelves[order.Temperature].WithdrawOrder(order.ID)
+// CancelOrder removes the order from the kitchen and calls off its courier
+func (k *Kitchen) CancelOrder(orderID string) bool {
+	_, shelf, ok := k.FindOrder(orderID)
 	if !ok {
-		_, ok = k.OverflowShelf.WithdrawOrder(order.ID)
+		return false
 	}
 
-	return ok
+	order, ok := shelf.WithdrawOrder(orderID)
+	if !ok {
+		return false
+	}
+
+	k.cancelCourier(orderID)
+
+	value := order.GetInherentValue(k.clock.Now())
+	k.count(func(stats *Stats) { stats.Cancelled++ })
+	k.logger.WithFields(k.getExtraFileds()).Infof("Order cancelled: %s", orderID)
+	k.emit(Event{Type: OrderCancelled, OrderID: orderID, Shelf: shelf.Name, Value: value})
+
+	if !k.isOverflowShelf(shelf) {
+		k.FillVacancies()
+	}
+
+	return true
+}
+
+// FindOrder returns the copy of the order by given ID and the shelf the order is on
+func (k *Kitchen) FindOrder(orderID string) (Order, *Shelf, bool) {
+	for _, s := range k.AllShelves() {
+		if order, ok := s.GetOrder(orderID); ok {
+			return order, s, true
+		}
+	}
+
+	return Order{}, nil, false
 }
 
 // Pause pauses the kitchen
 func (k *Kitchen) Pause() {
+	k.pausedMutex.Lock()
 	k.paused = true
-	k.OverflowShelf.Pause()
-	for _, s := range k.Shelves {
+	k.pausedMutex.Unlock()
+
+	for _, s := range k.AllShelves() {
 		s.Pause()
 	}
+	k.record(journalPaused, "", k.clock.Now())
 }
 
 // Unpause unpause the kitchen
 func (k *Kitchen) Unpause() {
+	k.pausedMutex.Lock()
 	k.paused = false
-	k.OverflowShelf.Unpause()
-	for _, s := range k.Shelves {
+	k.pausedMutex.Unlock()
+
+	for _, s := range k.AllShelves() {
 		s.Unpause()
 	}
+	k.record(journalUnpaused, "", k.clock.Now())
 }
 
 // IsOnPause returns kitchen state
 func (k *Kitchen) IsOnPause() bool {
+	k.pausedMutex.Lock()
+	defer k.pausedMutex.Unlock()
+
 	return k.paused
 }
 
+// sortedShelves returns shelves ordered by temperature, so that the kitchen walks them in the same order every run
+func (k *Kitchen) sortedShelves() []*Shelf {
+	result := make([]*Shelf, 0, len(k.Shelves))
+	for _, s := range k.Shelves {
+		result = append(result, s)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Temperature < result[j].Temperature
+	})
+
+	return result
+}
+
+// AllShelves returns the shelves ordered by temperature followed by the overflow shelves in priority order
+func (k *Kitchen) AllShelves() []*Shelf {
+	return append(k.sortedShelves(), k.OverflowShelves...)
+}
+
 func (k *Kitchen) getExtraFileds() log.Fields {
 	fields := log.Fields{
-		"ordersCount": k.OverflowShelf.OrdersCount(),
-		"overflowShelf": map[string]int{
-			"capacity":    k.OverflowShelf.Capacity,
-			"ordersCount": k.OverflowShelf.OrdersCount(),
-		},
+		"ordersCount": 0,
+	}
+
+	overflowShelves := make(map[string]map[string]int, len(k.OverflowShelves))
+	for _, s := range k.OverflowShelves {
+		fields["ordersCount"] = fields["ordersCount"].(int) + s.OrdersCount()
+
+		overflowShelves[s.Name] = map[string]int{
+			"capacity":    s.Capacity,
+			"ordersCount": s.OrdersCount(),
+		}
 	}
+	fields["overflowShelves"] = overflowShelves
 
 	for _, s := range k.Shelves {
 		fields["ordersCount"] = fields["ordersCount"].(int) + s.OrdersCount()
@@ -1,6 +1,7 @@
 package kitchen
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -8,20 +9,23 @@ import (
 )
 
 func init() {
-	c.Init("config.yml")
+	c.Init("../config.yml")
 }
 
 func TestKitchen(t *testing.T) {
+	clock := NewVirtualClock(time.Now())
+
 	t.Run("PlaceOrder", func(t *testing.T) {
-		frozenShelf := NewShelf("Frozen shelf", "frozen", 10, 1)
-		hotShelf := NewShelf("Hot shelf", "hot", 10, 1)
+		frozenShelf := NewShelf(clock, "Frozen shelf", "frozen", 10, 1)
+		hotShelf := NewShelf(clock, "Hot shelf", "hot", 10, 1)
 
 		k := New(
+			clock,
 			map[string]*Shelf{
 				"frozen": frozenShelf,
 				"hot":    hotShelf,
 			},
-			NewShelf("Overflow shelf", "any", 15, 3),
+			[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 15, 3)},
 		)
 
 		order := &Order{
@@ -52,15 +56,16 @@ func TestKitchen(t *testing.T) {
 	})
 
 	t.Run("PlaceOrder_Negative", func(t *testing.T) {
-		frozenShelf := NewShelf("Frozen shelf", "frozen", 10, 1)
-		hotShelf := NewShelf("Hot shelf", "hot", 10, 1)
+		frozenShelf := NewShelf(clock, "Frozen shelf", "frozen", 10, 1)
+		hotShelf := NewShelf(clock, "Hot shelf", "hot", 10, 1)
 
 		k := New(
+			clock,
 			map[string]*Shelf{
 				"frozen": frozenShelf,
 				"hot":    hotShelf,
 			},
-			NewShelf("Overflow shelf", "any", 15, 3),
+			[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 15, 3)},
 		)
 
 		order := &Order{
@@ -78,16 +83,51 @@ func TestKitchen(t *testing.T) {
 		}
 	})
 
+	t.Run("PlaceOrder_Duplicate", func(t *testing.T) {
+		k := New(
+			clock,
+			map[string]*Shelf{
+				"hot": NewShelf(clock, "Hot shelf", "hot", 10, 1),
+			},
+			[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 15, 3)},
+		)
+
+		first := &Order{ID: "1", Name: "Pizza", Temperature: "hot", ShelfLife: 20, DecayRate: 0.5}
+		k.PlaceOrder(first)
+
+		if err := k.Place(&Order{ID: "1", Name: "Burger", Temperature: "hot", ShelfLife: 20, DecayRate: 0.5}); err != ErrDuplicateOrder {
+			t.Errorf("got %v want %v", err, ErrDuplicateOrder)
+		}
+
+		if placed, _, _ := k.FindOrder("1"); placed.Name != first.Name {
+			t.Errorf("got %v want %v", placed.Name, first.Name)
+		}
+
+		if got, want := k.Stats().Received, 1; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+	})
+
+	t.Run("PlaceOrder_Closed", func(t *testing.T) {
+		k := New(clock, map[string]*Shelf{}, []*Shelf{NewShelf(clock, "Overflow shelf", "any", 15, 3)})
+		k.Close()
+
+		if err := k.Place(&Order{ID: "1", Temperature: "hot", ShelfLife: 20, DecayRate: 0.5}); err != ErrClosed {
+			t.Errorf("got %v want %v", err, ErrClosed)
+		}
+	})
+
 	t.Run("GetAvailableShelves", func(t *testing.T) {
-		frozenShelf := NewShelf("Frozen shelf", "frozen", 1, 1)
-		hotShelf := NewShelf("Hot shelf", "hot", 10, 1)
+		frozenShelf := NewShelf(clock, "Frozen shelf", "frozen", 1, 1)
+		hotShelf := NewShelf(clock, "Hot shelf", "hot", 10, 1)
 
 		k := New(
+			clock,
 			map[string]*Shelf{
 				"frozen": frozenShelf,
 				"hot":    hotShelf,
 			},
-			NewShelf("Overflow shelf", "any", 15, 3),
+			[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 15, 3)},
 		)
 
 		want := 2
@@ -106,15 +146,16 @@ func TestKitchen(t *testing.T) {
 	})
 
 	t.Run("IsEmpty", func(t *testing.T) {
-		frozenShelf := NewShelf("Frozen shelf", "frozen", 1, 1)
-		hotShelf := NewShelf("Hot shelf", "hot", 10, 1)
+		frozenShelf := NewShelf(clock, "Frozen shelf", "frozen", 1, 1)
+		hotShelf := NewShelf(clock, "Hot shelf", "hot", 10, 1)
 
 		k := New(
+			clock,
 			map[string]*Shelf{
 				"frozen": frozenShelf,
 				"hot":    hotShelf,
 			},
-			NewShelf("Overflow shelf", "any", 15, 3),
+			[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 15, 3)},
 		)
 
 		got := k.IsEmpty()
@@ -126,15 +167,16 @@ func TestKitchen(t *testing.T) {
 	})
 
 	t.Run("IsEmpty_Negative", func(t *testing.T) {
-		frozenShelf := NewShelf("Frozen shelf", "frozen", 1, 1)
-		hotShelf := NewShelf("Hot shelf", "hot", 10, 1)
+		frozenShelf := NewShelf(clock, "Frozen shelf", "frozen", 1, 1)
+		hotShelf := NewShelf(clock, "Hot shelf", "hot", 10, 1)
 
 		k := New(
+			clock,
 			map[string]*Shelf{
 				"frozen": frozenShelf,
 				"hot":    hotShelf,
 			},
-			NewShelf("Overflow shelf", "any", 15, 3),
+			[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 15, 3)},
 		)
 
 		frozenShelf.orders["1"] = &Order{ID: "1", Temperature: "frozen"}
@@ -147,17 +189,36 @@ func TestKitchen(t *testing.T) {
 		}
 	})
 
+	t.Run("IsEmpty_Overflow", func(t *testing.T) {
+		overflowShelf := NewShelf(clock, "Overflow shelf", "any", 15, 3)
+
+		k := New(
+			clock,
+			map[string]*Shelf{
+				"hot": NewShelf(clock, "Hot shelf", "hot", 10, 1),
+			},
+			[]*Shelf{overflowShelf},
+		)
+
+		overflowShelf.orders["1"] = &Order{ID: "1", Temperature: "frozen"}
+
+		if got := k.IsEmpty(); got {
+			t.Errorf("got %v want %v", got, false)
+		}
+	})
+
 	t.Run("RotateOrdersFromOverflowShelve", func(t *testing.T) {
-		frozenShelf := NewShelf("Frozen shelf", "frozen", 1, 1)
-		hotShelf := NewShelf("Hot shelf", "hot", 1, 1)
-		overflowShelf := NewShelf("Overflow shelf", "any", 2, 2)
+		frozenShelf := NewShelf(clock, "Frozen shelf", "frozen", 1, 1)
+		hotShelf := NewShelf(clock, "Hot shelf", "hot", 1, 1)
+		overflowShelf := NewShelf(clock, "Overflow shelf", "any", 2, 2)
 
 		k := New(
+			clock,
 			map[string]*Shelf{
 				"frozen": frozenShelf,
 				"hot":    hotShelf,
 			},
-			overflowShelf,
+			[]*Shelf{overflowShelf},
 		)
 
 		frozenShelf.orders["1"] = &Order{ID: "1", Temperature: "frozen"}
@@ -195,19 +256,74 @@ func TestKitchen(t *testing.T) {
 		}
 	})
 
+	t.Run("RotateOrdersFromOverflowShelve_ClosestToExpiring", func(t *testing.T) {
+		hotShelf := NewShelf(clock, "Hot shelf", "hot", 1, 1)
+		overflowShelf := NewShelf(clock, "Overflow shelf", "any", 2, 2)
+
+		k := New(
+			clock,
+			map[string]*Shelf{
+				"hot": hotShelf,
+			},
+			[]*Shelf{overflowShelf},
+		)
+
+		overflowShelf.AddOrder(&Order{ID: "1", Temperature: "hot", ShelfLife: 300, DecayRate: 0.5})
+		overflowShelf.AddOrder(&Order{ID: "2", Temperature: "hot", ShelfLife: 30, DecayRate: 0.5})
+
+		ok := k.RotateOrdersFromOverflowShelve(&Order{ID: "3", Temperature: "hot"})
+		if !ok {
+			t.Errorf("got %v want %v", ok, true)
+		}
+
+		if _, ok := hotShelf.orders["2"]; !ok {
+			t.Errorf("got %v want %v", ok, true)
+		}
+	})
+
+	t.Run("FillVacancies", func(t *testing.T) {
+		hotShelf := NewShelf(clock, "Hot shelf", "hot", 1, 1)
+		overflowShelf := NewShelf(clock, "Overflow shelf", "any", 2, 2)
+
+		k := New(
+			clock,
+			map[string]*Shelf{
+				"hot": hotShelf,
+			},
+			[]*Shelf{overflowShelf},
+		)
+
+		order := &Order{ID: "1", Temperature: "hot", ShelfLife: 300, DecayRate: 0.5}
+		hotShelf.orders[order.ID] = order
+		overflowShelf.AddOrder(&Order{ID: "2", Temperature: "hot", ShelfLife: 300, DecayRate: 0.5})
+
+		if !k.PickUpOrder(order) {
+			t.Errorf("got %v want %v", false, true)
+		}
+
+		if _, ok := hotShelf.orders["2"]; !ok {
+			t.Errorf("got %v want %v", ok, true)
+		}
+
+		if !overflowShelf.IsEmpty() {
+			t.Errorf("got %v want %v", overflowShelf.IsEmpty(), true)
+		}
+	})
+
 	t.Run("CreateCourier", func(t *testing.T) {
 		c.Config.Courier.Arrive.Duration = time.Second
 		c.Config.Courier.Arrive.Min = 1
 		c.Config.Courier.Arrive.Max = 2
 
-		frozenShelf := NewShelf("Frozen shelf", "frozen", 10, 1)
-		overflowShelf := NewShelf("Overflow shelf", "any", 2, 2)
+		frozenShelf := NewShelf(clock, "Frozen shelf", "frozen", 10, 1)
+		overflowShelf := NewShelf(clock, "Overflow shelf", "any", 2, 2)
 
 		k := New(
+			clock,
 			map[string]*Shelf{
 				"frozen": frozenShelf,
 			},
-			overflowShelf,
+			[]*Shelf{overflowShelf},
 		)
 
 		order := &Order{
@@ -218,7 +334,11 @@ func TestKitchen(t *testing.T) {
 
 		k.CreateCourier(order)
 
-		time.Sleep(time.Duration(c.Config.Courier.Arrive.Max) * time.Second)
+		if _, ok := frozenShelf.orders[order.ID]; !ok {
+			t.Errorf("got %v want %v", ok, true)
+		}
+
+		clock.Advance(time.Duration(2*c.Config.Courier.Arrive.Max) * c.Config.Courier.Arrive.Duration)
 
 		if _, ok := frozenShelf.orders[order.ID]; ok {
 			t.Errorf("got %v want %v", ok, false)
@@ -230,14 +350,15 @@ func TestKitchen(t *testing.T) {
 		c.Config.Courier.Arrive.Min = 1
 		c.Config.Courier.Arrive.Max = 2
 
-		frozenShelf := NewShelf("Frozen shelf", "frozen", 10, 1)
-		overflowShelf := NewShelf("Overflow shelf", "any", 2, 2)
+		frozenShelf := NewShelf(clock, "Frozen shelf", "frozen", 10, 1)
+		overflowShelf := NewShelf(clock, "Overflow shelf", "any", 2, 2)
 
 		k := New(
+			clock,
 			map[string]*Shelf{
 				"frozen": frozenShelf,
 			},
-			overflowShelf,
+			[]*Shelf{overflowShelf},
 		)
 
 		order := &Order{
@@ -255,4 +376,277 @@ func TestKitchen(t *testing.T) {
 			t.Errorf("got %v want %v", ok, false)
 		}
 	})
+
+	t.Run("Stats", func(t *testing.T) {
+		frozenShelf := NewShelf(clock, "Frozen shelf", "frozen", 10, 1)
+		overflowShelf := NewShelf(clock, "Overflow shelf", "any", 2, 2)
+
+		k := New(
+			clock,
+			map[string]*Shelf{
+				"frozen": frozenShelf,
+			},
+			[]*Shelf{overflowShelf},
+		)
+
+		k.PlaceOrder(&Order{ID: "1", Temperature: "frozen", ShelfLife: 20, DecayRate: 0.5})
+		k.PlaceOrder(&Order{ID: "2", Temperature: "hot", ShelfLife: 20, DecayRate: 0.5})
+
+		want := Stats{Received: 2, Placed: 1, Rejected: 1, OnShelves: 1}
+		got := k.Stats()
+		if got != want {
+			t.Errorf("got %+v want %+v", got, want)
+		}
+	})
+
+	t.Run("Report", func(t *testing.T) {
+		c.Config.Courier.Arrive.Duration = time.Second
+		c.Config.Courier.Arrive.Min = 2
+		c.Config.Courier.Arrive.Max = 2
+
+		frozenShelf := NewShelf(clock, "Frozen shelf", "frozen", 10, 1)
+		overflowShelf := NewShelf(clock, "Overflow shelf", "any", 2, 2)
+
+		k := New(
+			clock,
+			map[string]*Shelf{
+				"frozen": frozenShelf,
+			},
+			[]*Shelf{overflowShelf},
+		)
+
+		order := &Order{ID: "1", Temperature: "frozen", ShelfLife: 20, DecayRate: 0.5}
+		k.PlaceOrder(order)
+		k.CreateCourier(order)
+
+		clock.Advance(2 * time.Second)
+
+		report := k.Report()
+
+		if report.Delivered != 1 {
+			t.Errorf("got %v want %v", report.Delivered, 1)
+		}
+
+		if report.AverageValue != order.GetInherentValue(clock.Now()) {
+			t.Errorf("got %v want %v", report.AverageValue, order.GetInherentValue(clock.Now()))
+		}
+
+		if report.AverageCourierArrival != 2 || report.MaxCourierArrival != 2 {
+			t.Errorf("got %v want %v", report.AverageCourierArrival, 2)
+		}
+
+		if report.AverageCourierWait != 0 || report.MaxCourierWait != 0 {
+			t.Errorf("got %v want %v", report.AverageCourierWait, 0)
+		}
+
+		if report.AverageFoodWait != 2 || report.MaxFoodWait != 2 {
+			t.Errorf("got %v want %v", report.AverageFoodWait, 2)
+		}
+
+		want := []ShelfReport{
+			{Name: "Frozen shelf", Temperature: "frozen", Capacity: 10, PeakOccupancy: 1},
+			{Name: "Overflow shelf", Temperature: "any", Capacity: 2, PeakOccupancy: 0},
+		}
+		for i, s := range report.Shelves {
+			if s != want[i] {
+				t.Errorf("got %v want %v", s, want[i])
+			}
+		}
+	})
+
+	t.Run("CancelOrder", func(t *testing.T) {
+		c.Config.Courier.Arrive.Duration = time.Second
+		c.Config.Courier.Arrive.Min = 2
+		c.Config.Courier.Arrive.Max = 2
+
+		k := New(
+			clock,
+			map[string]*Shelf{
+				"frozen": NewShelf(clock, "Frozen shelf", "frozen", 10, 1),
+			},
+			[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 2, 2)},
+		)
+
+		order := &Order{ID: "1", Temperature: "frozen", ShelfLife: 20, DecayRate: 0.5}
+		k.PlaceOrder(order)
+		k.CreateCourier(order)
+
+		if !k.CancelOrder(order.ID) {
+			t.Fatalf("got %v want %v", false, true)
+		}
+
+		if k.CancelOrder(order.ID) {
+			t.Errorf("got %v want %v", true, false)
+		}
+
+		if k.PendingCouriers() != 0 {
+			t.Errorf("got %v want %v", k.PendingCouriers(), 0)
+		}
+
+		clock.Advance(2 * time.Second)
+
+		want := Stats{Received: 1, Placed: 1, Cancelled: 1}
+		if got := k.Stats(); got != want {
+			t.Errorf("got %+v want %+v", got, want)
+		}
+
+		status, _ := k.OrderStatus(order.ID)
+		if status.State != StateCancelled {
+			t.Errorf("got %v want %v", status.State, StateCancelled)
+		}
+	})
+
+	t.Run("CancelOrder_Fleet", func(t *testing.T) {
+		c.Config.Courier.Arrive.Duration = time.Second
+		c.Config.Courier.Arrive.Min = 2
+		c.Config.Courier.Arrive.Max = 2
+
+		k := New(
+			clock,
+			map[string]*Shelf{
+				"frozen": NewShelf(clock, "Frozen shelf", "frozen", 10, 1),
+			},
+			[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 2, 2)},
+			WithFleet(&Fleet{Size: 1}),
+		)
+
+		first := &Order{ID: "1", Temperature: "frozen", ShelfLife: 20, DecayRate: 0.5}
+		second := &Order{ID: "2", Temperature: "frozen", ShelfLife: 20, DecayRate: 0.5}
+		for _, order := range []*Order{first, second} {
+			k.PlaceOrder(order)
+			k.CreateCourier(order)
+		}
+
+		// the called off courier is free for the awaiting order
+		k.CancelOrder(first.ID)
+
+		if k.AwaitingOrders() != 0 || k.PendingCouriers() != 1 {
+			t.Errorf("got %v want %v", k.AwaitingOrders(), 0)
+		}
+
+		clock.Advance(2 * time.Second)
+
+		if got := k.Stats().Delivered; got != 1 {
+			t.Errorf("got %v want %v", got, 1)
+		}
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		clock := NewVirtualClock(time.Now())
+
+		k := New(
+			clock,
+			map[string]*Shelf{
+				"frozen": NewShelf(clock, "Frozen shelf", "frozen", 10, 1),
+			},
+			[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 2, 2)},
+			WithFleet(&Fleet{Size: 1, Shifts: []Shift{{Start: time.Hour, End: 2 * time.Hour, Size: 1}}}),
+		)
+
+		order := &Order{ID: "1", Temperature: "frozen", ShelfLife: 20, DecayRate: 0.5}
+		k.PlaceOrder(order)
+		k.CreateCourier(order)
+
+		k.Close()
+
+		if clock.Pending() != 0 {
+			t.Errorf("got %v want %v", clock.Pending(), 0)
+		}
+
+		select {
+		case <-k.Done():
+		default:
+			t.Errorf("got %v want %v", "open", "closed")
+		}
+
+		if k.PlaceOrder(&Order{ID: "2", Temperature: "frozen", ShelfLife: 20, DecayRate: 0.5}) {
+			t.Errorf("got %v want %v", true, false)
+		}
+
+		if k.PendingCouriers() != 0 || k.AwaitingOrders() != 0 {
+			t.Errorf("got %v want %v", k.PendingCouriers(), 0)
+		}
+	})
+
+	t.Run("WithContext", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		k := New(
+			clock,
+			map[string]*Shelf{},
+			[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 2, 2)},
+			WithContext(ctx),
+		)
+
+		cancel()
+
+		select {
+		case <-k.Done():
+		case <-time.After(time.Second):
+			t.Errorf("got %v want %v", "open", "closed")
+		}
+	})
+
+	t.Run("Pause_Concurrent", func(t *testing.T) {
+		k := New(
+			RealClock{},
+			map[string]*Shelf{},
+			[]*Shelf{NewShelf(RealClock{}, "Overflow shelf", "any", 2, 2)},
+		)
+		defer k.Close()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				k.IsOnPause()
+			}
+		}()
+
+		for i := 0; i < 100; i++ {
+			k.Pause()
+			k.Unpause()
+		}
+		<-done
+
+		if k.IsOnPause() {
+			t.Errorf("got %v want %v", k.IsOnPause(), false)
+		}
+	})
+
+	t.Run("WithCourierArrival", func(t *testing.T) {
+		c.Config.Order.Age.Duration = time.Second
+		c.Config.Courier.Arrive.Duration = time.Second
+		c.Config.Courier.Arrive.Min = 2
+		c.Config.Courier.Arrive.Max = 2
+
+		clock := NewVirtualClock(time.Now())
+		k := New(
+			clock,
+			map[string]*Shelf{"hot": NewShelf(clock, "Hot shelf", "hot", 2, 1)},
+			[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 2, 2)},
+			WithCourierArrival(CourierArrival{Min: 5, Max: 5, Unit: time.Minute}),
+			WithAgeUnit(time.Minute),
+		)
+
+		order := &Order{ID: "1", Temperature: "hot", ShelfLife: 100, DecayRate: 1}
+		k.PlaceOrder(order)
+		k.CreateCourier(order)
+
+		clock.Advance(4 * time.Minute)
+
+		if value := order.GetInherentValue(clock.Now()); value != 0.96 {
+			t.Errorf("got %v want %v", value, 0.96)
+		}
+
+		if k.Stats().Delivered != 0 {
+			t.Errorf("got %v want %v", k.Stats().Delivered, 0)
+		}
+
+		clock.Advance(time.Minute)
+
+		if k.Stats().Delivered != 1 {
+			t.Errorf("got %v want %v", k.Stats().Delivered, 1)
+		}
+	})
 }
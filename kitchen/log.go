@@ -0,0 +1,37 @@
+package kitchen
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	logOutput      io.Writer = os.Stderr
+	logOutputMutex sync.Mutex
+)
+
+// SetLogOutput sets the output of the loggers of kitchens and shelves created afterwards
+func SetLogOutput(w io.Writer) {
+	logOutputMutex.Lock()
+	logOutput = w
+	logOutputMutex.Unlock()
+}
+
+// newLogger creates new logger, the logs are discarded in the testing environment
+func newLogger() *log.Logger {
+	logger := log.New()
+
+	logOutputMutex.Lock()
+	logger.SetOutput(logOutput)
+	logOutputMutex.Unlock()
+
+	if os.Getenv("GO_ENV") == "testing" {
+		logger.SetOutput(ioutil.Discard)
+	}
+
+	return logger
+}
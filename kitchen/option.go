@@ -0,0 +1,90 @@
+package kitchen
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Option configures the kitchen
+type Option func(k *Kitchen)
+
+// WithRand sets the random generator used for every random choice of the kitchen
+func WithRand(rnd *rand.Rand) Option {
+	return func(k *Kitchen) {
+		k.rand = rnd
+	}
+}
+
+// WithContext closes the kitchen when the context is done
+func WithContext(ctx context.Context) Option {
+	return func(k *Kitchen) {
+		k.ctx = ctx
+	}
+}
+
+// WithDispatchStrategy sets the strategy that decides which order an arrived courier picks up
+func WithDispatchStrategy(strategy DispatchStrategy) Option {
+	return func(k *Kitchen) {
+		k.dispatch = strategy
+	}
+}
+
+// WithFleet limits the couriers to the fleet, without the fleet every order gets its own courier
+func WithFleet(fleet *Fleet) Option {
+	return func(k *Kitchen) {
+		k.fleet = fleet
+	}
+}
+
+// WithCompatibility lets orders sit on the compatible shelves when the shelf of their temperature is full
+func WithCompatibility(compatibility Compatibility) Option {
+	return func(k *Kitchen) {
+		k.compatibility = compatibility
+	}
+}
+
+// WithCourierArrival sets the range of the time couriers need to get to the kitchen, instead of the one from the config
+func WithCourierArrival(arrival CourierArrival) Option {
+	return func(k *Kitchen) {
+		k.courierArrival = &arrival
+	}
+}
+
+// WithAgeUnit sets the duration of one unit of the age of the placed orders, instead of the one from the config
+func WithAgeUnit(unit time.Duration) Option {
+	return func(k *Kitchen) {
+		k.ageUnit = unit
+	}
+}
+
+// WithJournal writes the mutations of the kitchen to the journal
+func WithJournal(journal *Journal) Option {
+	return func(k *Kitchen) {
+		k.journal = journal
+	}
+}
+
+// ShelfOption configures the shelf
+type ShelfOption func(s *Shelf)
+
+// WithDiscardPolicy sets the policy that chooses the order to discard when the shelf is full
+func WithDiscardPolicy(policy DiscardPolicy) ShelfOption {
+	return func(s *Shelf) {
+		s.discardPolicy = policy
+	}
+}
+
+// WithShelfContext closes the shelf when the context is done
+func WithShelfContext(ctx context.Context) ShelfOption {
+	return func(s *Shelf) {
+		s.ctx = ctx
+	}
+}
+
+// WithAcceptedTemperatures limits the orders an overflow shelf accepts to the temperatures
+func WithAcceptedTemperatures(temps ...string) ShelfOption {
+	return func(s *Shelf) {
+		s.accepts = temps
+	}
+}
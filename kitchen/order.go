@@ -1,5 +1,14 @@
 package kitchen
 
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	c "delivery/config"
+)
+
 // Order -
 type Order struct {
 	// ID of the order
@@ -12,17 +21,183 @@ type Order struct {
 	ShelfLife int `json:"shelfLife"`
 	// value deterioration modifier
 	DecayRate float64 `json:"decayRate"`
+	// curve the value follows, linear by default
+	Decay *DecaySpec `json:"decay,omitempty"`
+	// time the order was received, used by the replay of the orders
+	ReceivedAt *ReceivedAt `json:"receivedAt,omitempty"`
+
+	decayModel DecayModel
+	placedAt   time.Time
+	stints     []Stint
+	// duration of one unit of the age, from the config if it is not set
+	ageUnit time.Duration
+}
+
+// Stint is the time the order spent on a shelf
+type Stint struct {
+	// name of the shelf
+	Shelf string `json:"shelf"`
+	// decay modifier of the shelf multiplied by the decay multiplier for the temperature of the order
+	DecayModifier float64   `json:"decayModifier"`
+	From          time.Time `json:"from"`
+	// zero while the order is on the shelf
+	To time.Time `json:"to"`
+}
+
+// ReceivedAt is either the offset of the order from the start of the orders or the absolute time the order was received.
+// In JSON the offset is a number of seconds or a duration string like "1m30s", the absolute time is an RFC 3339 timestamp
+type ReceivedAt struct {
+	Offset time.Duration
+	// zero if the offset is set
+	Time time.Time
+}
+
+// UnmarshalJSON -
+func (r *ReceivedAt) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch value := value.(type) {
+	case float64:
+		*r = ReceivedAt{Offset: time.Duration(value * float64(time.Second))}
+		return nil
+	case string:
+		if offset, err := time.ParseDuration(value); err == nil {
+			*r = ReceivedAt{Offset: offset}
+			return nil
+		}
+
+		received, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return fmt.Errorf("invalid receivedAt '%s', want an offset or an RFC 3339 timestamp", value)
+		}
+
+		*r = ReceivedAt{Time: received}
+		return nil
+	}
+
+	return fmt.Errorf("invalid receivedAt %s, want an offset or an RFC 3339 timestamp", data)
+}
+
+// MarshalJSON -
+func (r ReceivedAt) MarshalJSON() ([]byte, error) {
+	if !r.Time.IsZero() {
+		return json.Marshal(r.Time.Format(time.RFC3339Nano))
+	}
+
+	return json.Marshal(r.Offset.Seconds())
+}
+
+// GetInherentValue returns order's have an inherent value that will deteriorate over time, based on the order’s ​shelfLife​ and decayRate​ fields.
+// The decay is integrated over the stints of the order on the shelves up to the given time and follows the decay model of the order
+func (o *Order) GetInherentValue(now time.Time) float64 {
+	return math.Max(o.model().Value(o, o.decayedAge(now)), 0)
+}
+
+// model returns the decay model of the order
+func (o *Order) model() DecayModel {
+	if o.decayModel == nil {
+		return LinearDecayModel{}
+	}
+
+	return o.decayModel
+}
+
+// PlacedAt returns the time the order was placed on the kitchen
+func (o *Order) PlacedAt() time.Time {
+	return o.placedAt
+}
 
-	shelfDecayModifier int
-	age                int
+// Stints returns the time the order spent on each shelf
+func (o *Order) Stints() []Stint {
+	return append([]Stint(nil), o.stints...)
 }
 
-// GetInherentValue returns order's have an inherent value that will deteriorate over time, based on the order’s ​shelfLife​ and decayRate​ fields
-func (o *Order) GetInherentValue() float64 {
-	return (float64(o.ShelfLife) - o.DecayRate*float64(o.age*o.shelfDecayModifier)) / float64(o.ShelfLife)
+// enterShelf starts the stint of the order on the shelf
+func (o *Order) enterShelf(shelf string, decayModifier float64, now time.Time) {
+	o.stints = append(o.stints, Stint{Shelf: shelf, DecayModifier: decayModifier, From: now})
 }
 
-// IncAge increases the age of the order by one
-func (o *Order) IncAge() {
-	o.age++
+// resume starts a new stint on the shelf of the last stint
+func (o *Order) resume(now time.Time) {
+	if len(o.stints) == 0 || o.currentStint() != nil {
+		return
+	}
+
+	last := o.stints[len(o.stints)-1]
+	o.enterShelf(last.Shelf, last.DecayModifier, now)
+}
+
+// leaveShelf ends the current stint of the order
+func (o *Order) leaveShelf(now time.Time) {
+	if stint := o.currentStint(); stint != nil {
+		stint.To = now
+	}
+}
+
+// currentStint returns the stint of the order on its current shelf or nil
+func (o *Order) currentStint() *Stint {
+	if len(o.stints) == 0 || !o.stints[len(o.stints)-1].To.IsZero() {
+		return nil
+	}
+
+	return &o.stints[len(o.stints)-1]
+}
+
+// decayedAge returns the age of the order in age units multiplied by the decay modifiers of the shelves it was on
+func (o *Order) decayedAge(now time.Time) float64 {
+	age := 0.0
+	for _, stint := range o.stints {
+		to := stint.To
+		if to.IsZero() {
+			to = now
+		}
+
+		age += float64(to.Sub(stint.From)) / float64(o.unit()) * stint.DecayModifier
+	}
+
+	return age
+}
+
+// remainingAge returns how long the order can stay on its current shelf before it expires, the order that is not decaying never expires
+func (o *Order) remainingAge(now time.Time) time.Duration {
+	stint := o.currentStint()
+	if stint == nil || stint.DecayModifier <= 0 {
+		return math.MaxInt64
+	}
+
+	remaining := math.Ceil((o.model().ExpiryAge(o) - o.decayedAge(now)) / stint.DecayModifier * float64(o.unit()))
+	if remaining >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+
+	return time.Duration(remaining)
+}
+
+// clone returns the copy of the order that does not share the stints
+func (o *Order) clone() Order {
+	order := *o
+	order.stints = o.Stints()
+
+	return order
+}
+
+// unit returns the duration of one unit of the age of the order
+func (o *Order) unit() time.Duration {
+	if o.ageUnit > 0 {
+		return o.ageUnit
+	}
+
+	return ageUnit()
+}
+
+// ageUnit returns the duration of one unit of the order age from the config
+func ageUnit() time.Duration {
+	if c.Config == nil || c.Config.Order.Age.Duration <= 0 {
+		return time.Second
+	}
+
+	return c.Config.Order.Age.Duration
 }
@@ -1,11 +1,19 @@
 package kitchen
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
+
+	c "delivery/config"
 )
 
 func TestOrder(t *testing.T) {
-	t.Run("IncAge", func(t *testing.T) {
+	c.Config.Order.Age.Duration = time.Second
+
+	placedAt := time.Now()
+
+	t.Run("GetInherentValue", func(t *testing.T) {
 		order := &Order{
 			ID:          "a8cfcb76-7f24-4420-a5ba-d46dd77bdffd",
 			Name:        "Banana Split",
@@ -13,38 +21,102 @@ func TestOrder(t *testing.T) {
 			ShelfLife:   20,
 			DecayRate:   0.63,
 		}
+		order.enterShelf("Frozen shelf", 1, placedAt)
 
-		want := order.age + 1
-
-		order.IncAge()
+		want := 0.9055
+		got := order.GetInherentValue(placedAt.Add(3 * time.Second))
 
-		got := order.age
+		if got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
 
+		// the value changes continuously between the age units
+		want = 0.968500
+		got = order.GetInherentValue(placedAt.Add(time.Second))
 		if got != want {
-			t.Errorf("got %d want %d", got, want)
+			t.Errorf("got %v want %v", got, want)
 		}
 	})
 
-	t.Run("GetInherentValue", func(t *testing.T) {
-		order := &Order{
-			ID:          "a8cfcb76-7f24-4420-a5ba-d46dd77bdffd",
-			Name:        "Banana Split",
-			Temperature: "frozen",
-			ShelfLife:   20,
-			DecayRate:   0.63,
+	t.Run("GetInherentValue_Moved", func(t *testing.T) {
+		order := &Order{ID: "1", ShelfLife: 100, DecayRate: 1}
+		order.enterShelf("Overflow shelf", 2, placedAt)
+		order.leaveShelf(placedAt.Add(10 * time.Second))
+		order.enterShelf("Hot shelf", 1, placedAt.Add(10*time.Second))
 
-			shelfDecayModifier: 1,
+		// 10 seconds on the overflow shelf and 20 seconds on the hot shelf
+		want := 0.6
+		got := order.GetInherentValue(placedAt.Add(30 * time.Second))
+		if got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+
+		stints := order.Stints()
+		if len(stints) != 2 || stints[0].Shelf != "Overflow shelf" || stints[1].To != (time.Time{}) {
+			t.Errorf("got %+v want %v", stints, "two stints")
 		}
+	})
 
-		order.IncAge()
-		order.IncAge()
-		order.IncAge()
+	t.Run("GetInherentValue_Expired", func(t *testing.T) {
+		order := &Order{ID: "1", ShelfLife: 10, DecayRate: 1}
+		order.enterShelf("Hot shelf", 1, placedAt)
 
-		want := 0.9055
-		got := order.GetInherentValue()
+		if got := order.GetInherentValue(placedAt.Add(time.Minute)); got != 0 {
+			t.Errorf("got %v want %v", got, 0)
+		}
+	})
 
+	t.Run("remainingAge", func(t *testing.T) {
+		order := &Order{ID: "1", ShelfLife: 100, DecayRate: 1}
+		order.enterShelf("Overflow shelf", 2, placedAt)
+
+		want := 40 * time.Second
+		got := order.remainingAge(placedAt.Add(10 * time.Second))
 		if got != want {
 			t.Errorf("got %v want %v", got, want)
 		}
 	})
 }
+
+func TestReceivedAt(t *testing.T) {
+	cases := []struct {
+		name    string
+		json    string
+		want    ReceivedAt
+		wantErr bool
+	}{
+		{"Seconds", `1.5`, ReceivedAt{Offset: 1500 * time.Millisecond}, false},
+		{"Duration", `"1m30s"`, ReceivedAt{Offset: 90 * time.Second}, false},
+		{"Time", `"2020-01-01T12:00:00Z"`, ReceivedAt{Time: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)}, false},
+		{"String_Negative", `"lunch"`, ReceivedAt{}, true},
+		{"Bool_Negative", `true`, ReceivedAt{}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got ReceivedAt
+			err := json.Unmarshal([]byte(tc.json), &got)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got %v want error %v", err, tc.wantErr)
+			}
+
+			if got.Offset != tc.want.Offset || !got.Time.Equal(tc.want.Time) {
+				t.Errorf("got %+v want %+v", got, tc.want)
+			}
+
+			if tc.wantErr {
+				return
+			}
+
+			data, err := json.Marshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var again ReceivedAt
+			if err := json.Unmarshal(data, &again); err != nil || again.Offset != got.Offset || !again.Time.Equal(got.Time) {
+				t.Errorf("got %s want %+v", data, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package kitchen
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOverflowShelves(t *testing.T) {
+	clock := NewVirtualClock(time.Now())
+
+	newKitchen := func() *Kitchen {
+		return New(
+			clock,
+			map[string]*Shelf{
+				"hot":  NewShelf(clock, "Hot shelf", "hot", 1, 1),
+				"cold": NewShelf(clock, "Cold shelf", "cold", 1, 1),
+			},
+			[]*Shelf{
+				NewShelf(clock, "Warm overflow shelf", "hot", 1, 2, WithAcceptedTemperatures("hot")),
+				NewShelf(clock, "Ambient overflow shelf", "any", 1, 3),
+			},
+		)
+	}
+
+	newOrder := func(id, temp string) *Order {
+		return &Order{ID: id, Temperature: temp, ShelfLife: 300, DecayRate: 0.5}
+	}
+
+	t.Run("PlaceOrder", func(t *testing.T) {
+		k := newKitchen()
+
+		cases := []struct {
+			order *Order
+			want  string
+		}{
+			{newOrder("1", "hot"), "Hot shelf"},
+			{newOrder("2", "hot"), "Warm overflow shelf"},
+			{newOrder("3", "cold"), "Cold shelf"},
+			{newOrder("4", "cold"), "Ambient overflow shelf"},
+		}
+
+		for _, tc := range cases {
+			if !k.PlaceOrder(tc.order) {
+				t.Fatalf("got %v want %v", false, true)
+			}
+
+			if _, shelf, _ := k.FindOrder(tc.order.ID); shelf.Name != tc.want {
+				t.Errorf("got %v want %v", shelf.Name, tc.want)
+			}
+		}
+	})
+
+	t.Run("RotateOrdersFromOverflowShelve", func(t *testing.T) {
+		k := newKitchen()
+
+		for _, order := range []*Order{newOrder("1", "hot"), newOrder("2", "hot"), newOrder("3", "cold"), newOrder("4", "hot")} {
+			k.PlaceOrder(order)
+		}
+
+		// the warm overflow shelf discards an order for the new hot order, the ambient one keeps its order
+		order := newOrder("5", "hot")
+		if !k.PlaceOrder(order) {
+			t.Fatalf("got %v want %v", false, true)
+		}
+
+		if _, shelf, _ := k.FindOrder(order.ID); shelf.Name != "Warm overflow shelf" {
+			t.Errorf("got %v want %v", shelf.Name, "Warm overflow shelf")
+		}
+
+		if got := k.OverflowShelves[1].OrdersCount(); got != 1 {
+			t.Errorf("got %v want %v", got, 1)
+		}
+
+		if got := k.Stats().Discarded; got != 1 {
+			t.Errorf("got %v want %v", got, 1)
+		}
+	})
+
+	t.Run("FillVacancies", func(t *testing.T) {
+		k := newKitchen()
+
+		hot := newOrder("1", "hot")
+		for _, order := range []*Order{hot, newOrder("2", "hot"), newOrder("3", "hot")} {
+			k.PlaceOrder(order)
+		}
+
+		k.PickUpOrder(hot)
+
+		// the warm overflow shelf is walked first
+		if _, shelf, _ := k.FindOrder("2"); shelf.Name != "Hot shelf" {
+			t.Errorf("got %v want %v", shelf.Name, "Hot shelf")
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package kitchen
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// NewRand creates new random generator by given seed, the generator is safe for concurrent use
+func NewRand(seed int64) *rand.Rand {
+	return rand.New(&lockedSource{source: rand.NewSource(seed).(rand.Source64)})
+}
+
+// lockedSource is a random source guarded by a mutex
+type lockedSource struct {
+	source rand.Source64
+	mutex  sync.Mutex
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.source.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.source.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.source.Seed(seed)
+}
@@ -1,15 +1,14 @@
 package kitchen
 
 import (
-	"io/ioutil"
+	"context"
+	"math"
 	"math/rand"
-	"os"
+	"sort"
 	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
-
-	c "delivery/config"
 )
 
 // Shelf -
@@ -21,27 +20,37 @@ type Shelf struct {
 	// max numbers of orders on the shelf
 	Capacity int
 
+	ctx           context.Context
+	cancel        context.CancelFunc
+	clock         Clock
 	decayModifier int
+	discardPolicy DiscardPolicy
+	accepts       []string
 	orders        map[string]*Order
+	expiryTimer   Timer
+	onVacancy     func()
+	emit          func(event Event)
+	expired       int
+	discarded     int
+	peak          int
 	paused        bool
 	logger        *log.Entry
 	mutex         sync.Mutex
 }
 
 // NewShelf creates new shelve by given parameters
-func NewShelf(name, temp string, cap, decayModifier int) *Shelf {
-	logger := log.New()
-
-	if os.Getenv("GO_ENV") == "testing" {
-		logger.SetOutput(ioutil.Discard)
-	}
+func NewShelf(clock Clock, name, temp string, cap, decayModifier int, options ...ShelfOption) *Shelf {
+	logger := newLogger()
 
 	shelf := &Shelf{
 		Name:        name,
 		Temperature: temp,
 		Capacity:    cap,
 
+		ctx:           context.Background(),
+		clock:         clock,
 		decayModifier: decayModifier,
+		discardPolicy: RandomDiscardPolicy{},
 		orders:        make(map[string]*Order, cap),
 		logger: logger.WithFields(log.Fields{
 			"source":      name,
@@ -50,53 +59,100 @@ func NewShelf(name, temp string, cap, decayModifier int) *Shelf {
 		}),
 	}
 
-	go func() {
-		for range time.Tick(c.Config.Order.Age.Duration) {
-			if shelf.paused {
-				continue
-			}
+	for _, option := range options {
+		option(shelf)
+	}
 
-			shelf.mutex.Lock()
-			for orderID, order := range shelf.orders {
-				order.IncAge()
-				if order.GetInherentValue() <= 0 {
-					delete(shelf.orders, orderID)
-					shelf.logger.WithFields(shelf.getExtraFileds()).Warningf("Order expired: %s", orderID)
-				}
-			}
-			shelf.mutex.Unlock()
-		}
-	}()
+	parent := shelf.ctx
+	shelf.ctx, shelf.cancel = context.WithCancel(parent)
+	if parent.Done() != nil {
+		go func() {
+			<-shelf.ctx.Done()
+			shelf.Close()
+		}()
+	}
 
 	return shelf
 }
 
+// Close stops aging the orders on the shelf
+func (s *Shelf) Close() {
+	s.cancel()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.stopExpiry()
+}
+
 // OrdersCount returns the count of the orders on the shelf
 func (s *Shelf) OrdersCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	return len(s.orders)
 }
 
+// Accepts checks if the orders of the temperature may sit on the shelf, a shelf without accepted temperatures accepts any order
+func (s *Shelf) Accepts(temp string) bool {
+	if len(s.accepts) == 0 {
+		return true
+	}
+
+	for _, accepted := range s.accepts {
+		if accepted == temp {
+			return true
+		}
+	}
+
+	return false
+}
+
 // HasEmptySeats checks if the shelf has empty seats
 func (s *Shelf) HasEmptySeats() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.hasEmptySeats()
+}
+
+// hasEmptySeats checks if the shelf has empty seats, the caller must hold the mutex
+func (s *Shelf) hasEmptySeats() bool {
 	return s.Capacity > len(s.orders)
 }
 
 // IsEmpty checks if the shelf is empty
 func (s *Shelf) IsEmpty() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	return len(s.orders) == 0
 }
 
 // AddOrder adds order to the shelf
 func (s *Shelf) AddOrder(order *Order) bool {
+	return s.addOrder(order, 1)
+}
+
+// addOrder adds order to the shelf, the order decays with the modifier of the shelf multiplied by the multiplier
+func (s *Shelf) addOrder(order *Order, decayMultiplier float64) bool {
 	s.mutex.Lock()
-	if !s.HasEmptySeats() {
-		s.mutex.Unlock()
+	if !s.hasEmptySeats() {
 		s.logger.WithFields(s.getExtraFileds()).Warn("There are no empty seats on the shelf")
+		s.mutex.Unlock()
 		return false
 	}
 
-	order.shelfDecayModifier = s.decayModifier
+	now := s.clock.Now()
+	order.enterShelf(s.Name, float64(s.decayModifier)*decayMultiplier, now)
+	if s.paused {
+		order.leaveShelf(now)
+	}
 	s.orders[order.ID] = order
+	if len(s.orders) > s.peak {
+		s.peak = len(s.orders)
+	}
+	s.scheduleExpiry()
 
 	s.mutex.Unlock()
 
@@ -105,11 +161,37 @@ func (s *Shelf) AddOrder(order *Order) bool {
 	return true
 }
 
+// restoreOrder puts the order back on the shelf with the stints it has spent on the shelves
+func (s *Shelf) restoreOrder(order *Order) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.hasEmptySeats() {
+		return false
+	}
+
+	now := s.clock.Now()
+	if s.paused {
+		order.leaveShelf(now)
+	} else {
+		order.resume(now)
+	}
+	s.orders[order.ID] = order
+	if len(s.orders) > s.peak {
+		s.peak = len(s.orders)
+	}
+	s.scheduleExpiry()
+
+	return true
+}
+
 // WithdrawOrder takes the order off the shelf
 func (s *Shelf) WithdrawOrder(orderID string) (order *Order, ok bool) {
 	s.mutex.Lock()
 	if order, ok = s.orders[orderID]; ok {
 		delete(s.orders, orderID)
+		order.leaveShelf(s.clock.Now())
+		s.scheduleExpiry()
 		s.logger.WithFields(s.getExtraFileds()).Infof("Order withdrawn: %s", order.ID)
 	}
 	s.mutex.Unlock()
@@ -120,9 +202,11 @@ func (s *Shelf) WithdrawOrder(orderID string) (order *Order, ok bool) {
 // DeleteOrder deletes the order from the shelf
 func (s *Shelf) DeleteOrder(orderID string) bool {
 	s.mutex.Lock()
-	_, ok := s.orders[orderID]
+	order, ok := s.orders[orderID]
 	if ok {
 		delete(s.orders, orderID)
+		order.leaveShelf(s.clock.Now())
+		s.scheduleExpiry()
 		s.logger.WithFields(s.getExtraFileds()).Infof("Order deleted: %s", orderID)
 	}
 	s.mutex.Unlock()
@@ -130,36 +214,71 @@ func (s *Shelf) DeleteOrder(orderID string) bool {
 	return ok
 }
 
-// DeleteRandomOrder deletes the random order from the shelf
-func (s *Shelf) DeleteRandomOrder() (result bool) {
+// DiscardOrder deletes the order chosen by the discard policy of the shelf
+func (s *Shelf) DiscardOrder(rnd *rand.Rand) (result bool) {
 	s.mutex.Lock()
 
-	rand.Seed(time.Now().UnixNano())
-	randValue := rand.Intn(len(s.orders) - 1)
+	orders := make([]*Order, 0, len(s.orders))
+	for _, orderID := range s.sortedOrderIDs() {
+		orders = append(orders, s.orders[orderID])
+	}
 
-	i := 0
-	for orderID := range s.orders {
-		if i == randValue {
-			delete(s.orders, orderID)
-			s.logger.WithFields(s.getExtraFileds()).Warnf("Order discarded: %s", orderID)
-			result = true
-			break
-		}
-		i++
+	var events []Event
+	now := s.clock.Now()
+	if order := s.discardPolicy.Choose(orders, now, rnd); order != nil {
+		delete(s.orders, order.ID)
+		order.leaveShelf(now)
+		s.scheduleExpiry()
+		s.discarded++
+		s.logger.WithFields(s.getExtraFileds()).Warnf("Order discarded: %s", order.ID)
+		events = append(events, s.newEvent(OrderDiscarded, order))
+		result = true
 	}
 
 	s.mutex.Unlock()
 
+	s.notify(events)
+
 	return result
 }
 
-// FindOrderByTemp returns order from the shelf by given temperature
-func (s *Shelf) FindOrderByTemp(temp string) (result *Order) {
+// Orders returns copies of the orders on the shelf sorted by ID
+func (s *Shelf) Orders() []Order {
 	s.mutex.Lock()
-	for _, order := range s.orders {
-		if order.Temperature == temp {
-			result = order
-			break
+	defer s.mutex.Unlock()
+
+	result := make([]Order, 0, len(s.orders))
+	for _, orderID := range s.sortedOrderIDs() {
+		result = append(result, s.orders[orderID].clone())
+	}
+
+	return result
+}
+
+// GetOrder returns the copy of the order on the shelf by given ID
+func (s *Shelf) GetOrder(orderID string) (Order, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if order, ok := s.orders[orderID]; ok {
+		return order.clone(), true
+	}
+
+	return Order{}, false
+}
+
+// FindOrderByTemp returns the order with one of the given temperatures that is the closest to expiring on the shelf
+func (s *Shelf) FindOrderByTemp(temps ...string) (result *Order) {
+	s.mutex.Lock()
+	now := s.clock.Now()
+	for _, orderID := range s.sortedOrderIDs() {
+		order := s.orders[orderID]
+
+		for _, temp := range temps {
+			if order.Temperature == temp && (result == nil || order.remainingAge(now) < result.remainingAge(now)) {
+				result = order
+				break
+			}
 		}
 	}
 	s.mutex.Unlock()
@@ -167,18 +286,138 @@ func (s *Shelf) FindOrderByTemp(temp string) (result *Order) {
 	return result
 }
 
-// Pause pauses the shelf
+// Pause pauses the shelf, the orders on the paused shelf do not decay
 func (s *Shelf) Pause() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.paused {
+		return
+	}
 	s.paused = true
+
+	now := s.clock.Now()
+	for _, order := range s.orders {
+		order.leaveShelf(now)
+	}
+	s.stopExpiry()
 }
 
 // Unpause unpause the shelf
 func (s *Shelf) Unpause() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.paused {
+		return
+	}
 	s.paused = false
+
+	now := s.clock.Now()
+	for _, order := range s.orders {
+		order.resume(now)
+	}
+	s.scheduleExpiry()
+}
+
+// expire removes the orders whose value dropped to zero
+func (s *Shelf) expire() {
+	s.mutex.Lock()
+	s.expiryTimer = nil
+
+	var events []Event
+	if !s.paused {
+		now := s.clock.Now()
+		for _, orderID := range s.sortedOrderIDs() {
+			order := s.orders[orderID]
+			if order.remainingAge(now) <= 0 {
+				delete(s.orders, orderID)
+				order.leaveShelf(now)
+				s.logger.WithFields(s.getExtraFileds()).Warningf("Order expired: %s", orderID)
+				events = append(events, s.newEvent(OrderExpired, order))
+			}
+		}
+	}
+
+	s.expired += len(events)
+	s.scheduleExpiry()
+	s.mutex.Unlock()
+
+	s.notify(events)
+
+	if len(events) > 0 && s.onVacancy != nil {
+		s.onVacancy()
+	}
+}
+
+func (s *Shelf) newEvent(eventType EventType, order *Order) Event {
+	now := s.clock.Now()
+
+	return Event{
+		Type:    eventType,
+		Time:    now,
+		OrderID: order.ID,
+		Shelf:   s.Name,
+		Value:   order.GetInherentValue(now),
+	}
+}
+
+// notify passes the events to the kitchen, the caller must not hold the mutex
+func (s *Shelf) notify(events []Event) {
+	if s.emit == nil {
+		return
+	}
+
+	for _, event := range events {
+		s.emit(event)
+	}
+}
+
+// scheduleExpiry sets the timer to the time the first order on the shelf expires
+func (s *Shelf) scheduleExpiry() {
+	s.stopExpiry()
+
+	if len(s.orders) == 0 || s.paused || s.ctx.Err() != nil {
+		return
+	}
+
+	now := s.clock.Now()
+	next := time.Duration(math.MaxInt64)
+	for _, order := range s.orders {
+		if remaining := order.remainingAge(now); remaining < next {
+			next = remaining
+		}
+	}
+
+	if next == math.MaxInt64 {
+		return
+	}
+
+	s.expiryTimer = s.clock.AfterFunc(next, s.expire)
+}
+
+// stopExpiry stops the expiry timer
+func (s *Shelf) stopExpiry() {
+	if s.expiryTimer != nil {
+		s.expiryTimer.Stop()
+		s.expiryTimer = nil
+	}
+}
+
+func (s *Shelf) sortedOrderIDs() []string {
+	result := make([]string, 0, len(s.orders))
+	for orderID := range s.orders {
+		result = append(result, orderID)
+	}
+
+	sort.Strings(result)
+
+	return result
 }
 
+// getExtraFileds returns the log fields of the shelf, the caller must hold the mutex
 func (s *Shelf) getExtraFileds() log.Fields {
 	return log.Fields{
-		"ordersCount": s.OrdersCount(),
+		"ordersCount": len(s.orders),
 	}
 }
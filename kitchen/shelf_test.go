@@ -3,11 +3,16 @@ package kitchen
 import (
 	"fmt"
 	"testing"
+	"time"
+
+	c "delivery/config"
 )
 
 func TestShelf(t *testing.T) {
+	clock := NewVirtualClock(time.Now())
+
 	t.Run("OrdersCount", func(t *testing.T) {
-		shelf := NewShelf("Cold shelf", "cold", 10, 1)
+		shelf := NewShelf(clock, "Cold shelf", "cold", 10, 1)
 
 		want := 5
 		for i := 0; i < want; i++ {
@@ -21,8 +26,30 @@ func TestShelf(t *testing.T) {
 		}
 	})
 
+	t.Run("OrdersCount_Concurrent", func(t *testing.T) {
+		shelf := NewShelf(clock, "Cold shelf", "cold", 100, 1)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				shelf.OrdersCount()
+				shelf.HasEmptySeats()
+			}
+		}()
+
+		for i := 0; i < 100; i++ {
+			shelf.AddOrder(&Order{ID: fmt.Sprintf("%d", i), ShelfLife: 100, DecayRate: 1})
+		}
+		<-done
+
+		if got, want := shelf.OrdersCount(), 100; got != want {
+			t.Errorf("got %d want %d", got, want)
+		}
+	})
+
 	t.Run("HasEmptySeats", func(t *testing.T) {
-		shelf := NewShelf("Cold shelf", "cold", 10, 1)
+		shelf := NewShelf(clock, "Cold shelf", "cold", 10, 1)
 
 		for i := 0; i < 5; i++ {
 			shelf.orders[fmt.Sprintf("%d", i)] = &Order{}
@@ -37,7 +64,7 @@ func TestShelf(t *testing.T) {
 	})
 
 	t.Run("HasEmptySeats_Negative", func(t *testing.T) {
-		shelf := NewShelf("Cold shelf", "cold", 5, 1)
+		shelf := NewShelf(clock, "Cold shelf", "cold", 5, 1)
 
 		for i := 0; i < 5; i++ {
 			shelf.orders[fmt.Sprintf("%d", i)] = &Order{}
@@ -53,7 +80,7 @@ func TestShelf(t *testing.T) {
 	})
 
 	t.Run("IsEmpty", func(t *testing.T) {
-		shelf := NewShelf("Cold shelf", "cold", 5, 1)
+		shelf := NewShelf(clock, "Cold shelf", "cold", 5, 1)
 
 		got := shelf.IsEmpty()
 		want := true
@@ -64,7 +91,7 @@ func TestShelf(t *testing.T) {
 	})
 
 	t.Run("IsEmpty_Negative", func(t *testing.T) {
-		shelf := NewShelf("Cold shelf", "cold", 5, 1)
+		shelf := NewShelf(clock, "Cold shelf", "cold", 5, 1)
 
 		for i := 0; i < 5; i++ {
 			shelf.orders[fmt.Sprintf("%d", i)] = &Order{}
@@ -80,7 +107,7 @@ func TestShelf(t *testing.T) {
 	})
 
 	t.Run("AddOrder", func(t *testing.T) {
-		shelf := NewShelf("Cold shelf", "cold", 5, 1)
+		shelf := NewShelf(clock, "Cold shelf", "cold", 5, 1)
 
 		for i := 0; i < 3; i++ {
 			shelf.orders[fmt.Sprintf("%d", i)] = &Order{}
@@ -95,7 +122,7 @@ func TestShelf(t *testing.T) {
 	})
 
 	t.Run("AddOrder_Negative", func(t *testing.T) {
-		shelf := NewShelf("Cold shelf", "cold", 5, 1)
+		shelf := NewShelf(clock, "Cold shelf", "cold", 5, 1)
 
 		for i := 0; i < 5; i++ {
 			shelf.orders[fmt.Sprintf("%d", i)] = &Order{}
@@ -110,7 +137,7 @@ func TestShelf(t *testing.T) {
 	})
 
 	t.Run("WithdrawOrder", func(t *testing.T) {
-		shelf := NewShelf("Cold shelf", "cold", 5, 1)
+		shelf := NewShelf(clock, "Cold shelf", "cold", 5, 1)
 
 		ordersCount := 5
 		for i := 0; i < ordersCount; i++ {
@@ -135,7 +162,7 @@ func TestShelf(t *testing.T) {
 	})
 
 	t.Run("WithdrawOrder_Negative", func(t *testing.T) {
-		shelf := NewShelf("Cold shelf", "cold", 5, 1)
+		shelf := NewShelf(clock, "Cold shelf", "cold", 5, 1)
 
 		ordersCount := 6
 		for i := 0; i < ordersCount; i++ {
@@ -155,7 +182,7 @@ func TestShelf(t *testing.T) {
 	})
 
 	t.Run("DeleteOrder", func(t *testing.T) {
-		shelf := NewShelf("Cold shelf", "cold", 5, 1)
+		shelf := NewShelf(clock, "Cold shelf", "cold", 5, 1)
 
 		ordersCount := 6
 		for i := 0; i < ordersCount; i++ {
@@ -175,7 +202,7 @@ func TestShelf(t *testing.T) {
 	})
 
 	t.Run("DeleteOrder_Negative", func(t *testing.T) {
-		shelf := NewShelf("Cold shelf", "cold", 5, 1)
+		shelf := NewShelf(clock, "Cold shelf", "cold", 5, 1)
 
 		ordersCount := 6
 		for i := 0; i < ordersCount; i++ {
@@ -194,8 +221,8 @@ func TestShelf(t *testing.T) {
 		}
 	})
 
-	t.Run("DeleteRandomOrder", func(t *testing.T) {
-		shelf := NewShelf("Cold shelf", "cold", 5, 1)
+	t.Run("DiscardOrder", func(t *testing.T) {
+		shelf := NewShelf(clock, "Cold shelf", "cold", 5, 1)
 
 		ordersCount := 6
 		for i := 0; i < ordersCount; i++ {
@@ -203,7 +230,7 @@ func TestShelf(t *testing.T) {
 			shelf.orders[orderID] = &Order{ID: orderID}
 		}
 
-		ok := shelf.DeleteRandomOrder()
+		ok := shelf.DiscardOrder(NewRand(1))
 
 		if !ok {
 			t.Errorf("got %v want %v", ok, true)
@@ -215,7 +242,7 @@ func TestShelf(t *testing.T) {
 	})
 
 	t.Run("FindOrderByTemp", func(t *testing.T) {
-		shelf := NewShelf("Overflow shelf", "any", 10, 2)
+		shelf := NewShelf(clock, "Overflow shelf", "any", 10, 2)
 
 		for i := 0; i < 3; i++ {
 			orderID := fmt.Sprintf("%d", i)
@@ -244,7 +271,7 @@ func TestShelf(t *testing.T) {
 	})
 
 	t.Run("FindOrderByTemp_Negative", func(t *testing.T) {
-		shelf := NewShelf("Overflow shelf", "any", 10, 2)
+		shelf := NewShelf(clock, "Overflow shelf", "any", 10, 2)
 
 		for i := 0; i < 3; i++ {
 			orderID := fmt.Sprintf("%d", i)
@@ -257,4 +284,63 @@ func TestShelf(t *testing.T) {
 			t.Errorf("got %v want %v", order, nil)
 		}
 	})
+
+	t.Run("Age", func(t *testing.T) {
+		c.Config.Order.Age.Duration = time.Second
+
+		shelf := NewShelf(clock, "Cold shelf", "cold", 5, 1)
+
+		shelf.AddOrder(&Order{ID: "1", ShelfLife: 2, DecayRate: 1})
+		shelf.AddOrder(&Order{ID: "2", ShelfLife: 4, DecayRate: 1})
+
+		clock.Advance(2 * time.Second)
+
+		if _, ok := shelf.orders["1"]; ok {
+			t.Errorf("got %v want %v", ok, false)
+		}
+
+		if _, ok := shelf.orders["2"]; !ok {
+			t.Errorf("got %v want %v", ok, true)
+		}
+
+		clock.Advance(2 * time.Second)
+
+		if !shelf.IsEmpty() {
+			t.Errorf("got %v want %v", shelf.IsEmpty(), true)
+		}
+	})
+
+	t.Run("WithdrawOrder_Expiry", func(t *testing.T) {
+		c.Config.Order.Age.Duration = time.Second
+
+		clock := NewVirtualClock(time.Now())
+		shelf := NewShelf(clock, "Cold shelf", "cold", 5, 1)
+
+		shelf.AddOrder(&Order{ID: "1", ShelfLife: 2, DecayRate: 1})
+		shelf.WithdrawOrder("1")
+
+		if clock.Pending() != 0 {
+			t.Errorf("got %v want %v", clock.Pending(), 0)
+		}
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		c.Config.Order.Age.Duration = time.Second
+
+		clock := NewVirtualClock(time.Now())
+		shelf := NewShelf(clock, "Cold shelf", "cold", 5, 1)
+
+		shelf.AddOrder(&Order{ID: "1", ShelfLife: 2, DecayRate: 1})
+		shelf.Close()
+
+		if clock.Pending() != 0 {
+			t.Errorf("got %v want %v", clock.Pending(), 0)
+		}
+
+		clock.Advance(2 * time.Second)
+
+		if shelf.IsEmpty() {
+			t.Errorf("got %v want %v", shelf.IsEmpty(), false)
+		}
+	})
 }
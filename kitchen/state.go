@@ -0,0 +1,295 @@
+package kitchen
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// State is what the kitchen needs to continue after a restart: the orders on the shelves with their ages and the couriers on their way
+type State struct {
+	// time the state was taken
+	Time time.Time `json:"time"`
+	// time the kitchen was started, the shifts of the fleet are relative to it
+	StartedAt time.Time `json:"startedAt"`
+	Paused    bool      `json:"paused"`
+	// orders on the shelves sorted by the time they were placed
+	Orders []SavedOrder `json:"orders"`
+	// couriers that have not arrived yet
+	Couriers []SavedCourier `json:"couriers"`
+	Stats    SavedStats     `json:"stats"`
+	// number of the orders taken from the orders file, the orders of other sources are not counted
+	Cursor int `json:"cursor"`
+}
+
+// SavedOrder is the order on a shelf with the stints it has spent on the shelves
+type SavedOrder struct {
+	Order    Order     `json:"order"`
+	Shelf    string    `json:"shelf"`
+	PlacedAt time.Time `json:"placedAt"`
+	Stints   []Stint   `json:"stints"`
+}
+
+// SavedCourier is the courier on its way to the kitchen
+type SavedCourier struct {
+	ID int `json:"id"`
+	// IDs of the orders the courier is sent for
+	Orders      []string  `json:"orders"`
+	RequestedAt time.Time `json:"requestedAt"`
+	// expected arrival time of the courier
+	ETA time.Time `json:"eta"`
+}
+
+// SavedStats is the stats of the kitchen with the durations, which are left out of the stats JSON
+type SavedStats struct {
+	Stats
+	CourierArrivalTotal time.Duration `json:"courierArrivalTotal"`
+	CourierArrivalMax   time.Duration `json:"courierArrivalMax"`
+	CourierWaitTotal    time.Duration `json:"courierWaitTotal"`
+	CourierWaitMax      time.Duration `json:"courierWaitMax"`
+	FoodWaitTotal       time.Duration `json:"foodWaitTotal"`
+	FoodWaitMax         time.Duration `json:"foodWaitMax"`
+}
+
+// saveStats returns the stats with the durations
+func saveStats(stats Stats) SavedStats {
+	return SavedStats{
+		Stats:               stats,
+		CourierArrivalTotal: stats.CourierArrivalTotal,
+		CourierArrivalMax:   stats.CourierArrivalMax,
+		CourierWaitTotal:    stats.CourierWaitTotal,
+		CourierWaitMax:      stats.CourierWaitMax,
+		FoodWaitTotal:       stats.FoodWaitTotal,
+		FoodWaitMax:         stats.FoodWaitMax,
+	}
+}
+
+// stats returns the saved stats
+func (s SavedStats) stats() Stats {
+	stats := s.Stats
+	stats.CourierArrivalTotal = s.CourierArrivalTotal
+	stats.CourierArrivalMax = s.CourierArrivalMax
+	stats.CourierWaitTotal = s.CourierWaitTotal
+	stats.CourierWaitMax = s.CourierWaitMax
+	stats.FoodWaitTotal = s.FoodWaitTotal
+	stats.FoodWaitMax = s.FoodWaitMax
+
+	return stats
+}
+
+// State returns the current state of the kitchen
+func (k *Kitchen) State() State {
+	state := State{
+		Time:      k.clock.Now(),
+		StartedAt: k.startedAt,
+		Paused:    k.IsOnPause(),
+		Orders:    []SavedOrder{},
+		Couriers:  []SavedCourier{},
+		Cursor:    k.Cursor(),
+	}
+
+	for _, s := range k.AllShelves() {
+		for _, order := range s.Orders() {
+			state.Orders = append(state.Orders, saveOrder(order, s))
+		}
+	}
+	sort.SliceStable(state.Orders, func(i, j int) bool {
+		return state.Orders[i].PlacedAt.Before(state.Orders[j].PlacedAt)
+	})
+
+	k.couriersMutex.Lock()
+	saved := make(map[*Courier]bool)
+	for _, courier := range k.dispatched {
+		if !saved[courier] {
+			saved[courier] = true
+			state.Couriers = append(state.Couriers, saveCourier(courier))
+		}
+	}
+	k.couriersMutex.Unlock()
+	sort.Slice(state.Couriers, func(i, j int) bool {
+		return state.Couriers[i].ID < state.Couriers[j].ID
+	})
+
+	stats := k.Stats()
+	stats.OnShelves = 0
+	state.Stats = saveStats(stats)
+
+	return state
+}
+
+// Restore puts the kitchen into the state, the kitchen must not have received orders yet.
+// The time between the state and now is treated as a pause: the orders do not age and the couriers need the rest of their way.
+// Orders that had no courier on the way get one
+func (k *Kitchen) Restore(state State) error {
+	if k.ctx.Err() != nil {
+		return fmt.Errorf("kitchen is closed")
+	}
+
+	shelves := make(map[string]*Shelf)
+	for _, s := range k.AllShelves() {
+		shelves[s.Name] = s
+	}
+
+	now := k.clock.Now()
+	downtime := now.Sub(state.Time)
+	if state.Time.IsZero() || downtime < 0 {
+		downtime = 0
+	}
+
+	if state.Paused {
+		k.Pause()
+	}
+
+	orders := make(map[string]*Order, len(state.Orders))
+	for _, saved := range state.Orders {
+		shelf, ok := shelves[saved.Shelf]
+		if !ok {
+			return fmt.Errorf("there is no shelf '%s' for order with ID %s", saved.Shelf, saved.Order.ID)
+		}
+
+		order, err := k.restoreOrder(saved, downtime)
+		if err != nil {
+			return err
+		}
+
+		if !shelf.restoreOrder(order) {
+			return fmt.Errorf("there are no empty seats on shelf '%s' for order with ID %s", shelf.Name, order.ID)
+		}
+		orders[order.ID] = order
+
+		k.track(Event{Type: OrderReceived, Time: order.placedAt, OrderID: order.ID, Value: order.GetInherentValue(now)})
+		k.track(Event{Type: OrderPlaced, Time: order.placedAt, OrderID: order.ID, Shelf: shelf.Name, Value: order.GetInherentValue(now)})
+	}
+
+	k.statsMutex.Lock()
+	k.stats = state.Stats.stats()
+	k.statsMutex.Unlock()
+
+	k.cursorMutex.Lock()
+	k.cursor = state.Cursor
+	k.cursorMutex.Unlock()
+
+	if !state.StartedAt.IsZero() {
+		k.startedAt = state.StartedAt.Add(downtime)
+	}
+
+	if k.fleet != nil {
+		elapsed := now.Sub(k.startedAt)
+		for _, shift := range k.fleet.Shifts {
+			if shift.Start > elapsed {
+				k.afterFunc(shift.Start-elapsed, k.assignCouriers)
+			}
+		}
+	}
+
+	withCourier := make(map[string]bool, len(orders))
+	for _, saved := range state.Couriers {
+		courier := &Courier{RequestedAt: saved.RequestedAt.Add(downtime)}
+		for _, orderID := range saved.Orders {
+			order, ok := orders[orderID]
+			if !ok {
+				// the order has left the kitchen, the courier misses it
+				order = &Order{ID: orderID}
+			}
+			courier.Orders = append(courier.Orders, order)
+			withCourier[orderID] = true
+		}
+		if len(courier.Orders) == 0 {
+			continue
+		}
+
+		k.couriersMutex.Lock()
+		courier.id = saved.ID
+		if saved.ID > k.lastCourierID {
+			k.lastCourierID = saved.ID
+		}
+		if k.fleet != nil {
+			k.busyCouriers++
+		}
+		k.couriers++
+		k.couriersMutex.Unlock()
+
+		delay := saved.ETA.Sub(state.Time)
+		if delay < 0 {
+			delay = 0
+		}
+
+		k.sendCourier(courier, delay)
+	}
+
+	for _, saved := range state.Orders {
+		if !withCourier[saved.Order.ID] {
+			k.CreateCourier(orders[saved.Order.ID])
+		}
+	}
+
+	return nil
+}
+
+// AdvanceCursor counts the order taken from the orders file, the count is saved with the state so the file is resumed after a restart.
+// The order is counted before it is placed, so the journal entry of the received order carries the count
+func (k *Kitchen) AdvanceCursor() {
+	k.cursorMutex.Lock()
+	k.cursor++
+	k.cursorMutex.Unlock()
+}
+
+// Cursor returns the number of the orders taken from the orders file
+func (k *Kitchen) Cursor() int {
+	k.cursorMutex.Lock()
+	defer k.cursorMutex.Unlock()
+
+	return k.cursor
+}
+
+// restoreOrder returns the saved order with the stints shifted by the downtime
+func (k *Kitchen) restoreOrder(saved SavedOrder, downtime time.Duration) (*Order, error) {
+	order := saved.Order
+
+	model, err := NewDecayModel(order.Decay)
+	if err != nil {
+		return nil, fmt.Errorf("order %s cannot be restored: %s", order.ID, err)
+	}
+	order.decayModel = model
+
+	if k.ageUnit > 0 {
+		order.ageUnit = k.ageUnit
+	}
+	order.placedAt = saved.PlacedAt.Add(downtime)
+
+	order.stints = make([]Stint, 0, len(saved.Stints))
+	for _, stint := range saved.Stints {
+		stint.From = stint.From.Add(downtime)
+		if !stint.To.IsZero() {
+			stint.To = stint.To.Add(downtime)
+		}
+		order.stints = append(order.stints, stint)
+	}
+
+	return &order, nil
+}
+
+// saveOrder returns the copy of the order on the shelf
+func saveOrder(order Order, shelf *Shelf) SavedOrder {
+	return SavedOrder{
+		Order:    order,
+		Shelf:    shelf.Name,
+		PlacedAt: order.placedAt,
+		Stints:   order.Stints(),
+	}
+}
+
+// saveCourier returns the state of the courier, the caller must hold the couriers mutex
+func saveCourier(courier *Courier) SavedCourier {
+	saved := SavedCourier{
+		ID:          courier.id,
+		Orders:      make([]string, 0, len(courier.Orders)),
+		RequestedAt: courier.RequestedAt,
+		ETA:         courier.eta,
+	}
+	for _, order := range courier.Orders {
+		saved.Orders = append(saved.Orders, order.ID)
+	}
+
+	return saved
+}
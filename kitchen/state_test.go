@@ -0,0 +1,132 @@
+package kitchen
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestState(t *testing.T) {
+	newKitchen := func(clock Clock) *Kitchen {
+		return New(
+			clock,
+			map[string]*Shelf{
+				"hot": NewShelf(clock, "Hot shelf", "hot", 10, 1),
+			},
+			[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 10, 2)},
+			WithCourierArrival(CourierArrival{Min: 4, Max: 4, Unit: time.Second}),
+			WithAgeUnit(time.Second),
+		)
+	}
+
+	clock := NewVirtualClock(time.Now())
+	k := newKitchen(clock)
+
+	withCourier := &Order{ID: "1", Temperature: "hot", ShelfLife: 100, DecayRate: 1}
+	withoutCourier := &Order{ID: "2", Temperature: "hot", ShelfLife: 100, DecayRate: 1}
+
+	k.AdvanceCursor()
+	k.PlaceOrder(withCourier)
+	k.CreateCourier(withCourier)
+	// the order of the HTTP API is not taken from the orders file
+	k.PlaceOrder(withoutCourier)
+	clock.Advance(time.Second)
+
+	state := k.State()
+
+	t.Run("State", func(t *testing.T) {
+		if len(state.Orders) != 2 || state.Orders[0].Shelf != "Hot shelf" {
+			t.Fatalf("got %+v want %v", state.Orders, "2 orders on the hot shelf")
+		}
+
+		if len(state.Couriers) != 1 || state.Couriers[0].ETA.Sub(state.Time) != 3*time.Second {
+			t.Errorf("got %+v want %v", state.Couriers, "courier in 3 seconds")
+		}
+
+		if state.Stats.Received != 2 || state.Stats.Placed != 2 {
+			t.Errorf("got %+v want %v", state.Stats, "2 received and placed orders")
+		}
+
+		if state.Cursor != 1 {
+			t.Errorf("got %v want %v", state.Cursor, 1)
+		}
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		// the kitchen restarts an hour later
+		restarted := NewVirtualClock(clock.Now().Add(time.Hour))
+		r := newKitchen(restarted)
+
+		if err := r.Restore(state); err != nil {
+			t.Fatal(err)
+		}
+
+		order, shelf, ok := r.FindOrder(withCourier.ID)
+		if !ok || shelf.Name != "Hot shelf" {
+			t.Fatalf("got %v want %v", ok, true)
+		}
+
+		got := order.GetInherentValue(restarted.Now())
+		want := withCourier.GetInherentValue(clock.Now())
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("got %v want %v", got, want)
+		}
+
+		if got, want := r.Cursor(), 1; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+
+		if got, want := r.PendingCouriers(), 2; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+
+		restarted.Advance(3 * time.Second)
+
+		if _, _, ok := r.FindOrder(withCourier.ID); ok {
+			t.Errorf("got %v want %v", ok, false)
+		}
+
+		if got, want := r.Stats().Delivered, 1; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+
+		restarted.Advance(time.Second)
+
+		if got, want := r.Stats(), (Stats{Received: 2, Placed: 2, Delivered: 2, CouriersArrived: 2}); got.Received != want.Received || got.Delivered != want.Delivered || got.CouriersArrived != want.CouriersArrived {
+			t.Errorf("got %+v want %+v", got, want)
+		}
+	})
+
+	t.Run("Restore_Paused", func(t *testing.T) {
+		paused := state
+		paused.Paused = true
+
+		restarted := NewVirtualClock(clock.Now().Add(time.Hour))
+		r := newKitchen(restarted)
+
+		if err := r.Restore(paused); err != nil {
+			t.Fatal(err)
+		}
+
+		if !r.IsOnPause() {
+			t.Errorf("got %v want %v", r.IsOnPause(), true)
+		}
+
+		before, _, _ := r.FindOrder(withoutCourier.ID)
+		restarted.Advance(10 * time.Second)
+		after, _, _ := r.FindOrder(withoutCourier.ID)
+
+		if got, want := after.GetInherentValue(restarted.Now()), before.GetInherentValue(restarted.Now()); got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+	})
+
+	t.Run("Restore_UnknownShelf", func(t *testing.T) {
+		unknown := state
+		unknown.Orders = []SavedOrder{{Order: Order{ID: "3", Temperature: "cold"}, Shelf: "Cold shelf"}}
+
+		if err := newKitchen(NewVirtualClock(time.Now())).Restore(unknown); err == nil {
+			t.Errorf("got %v want %v", err, "error")
+		}
+	})
+}
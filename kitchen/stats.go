@@ -0,0 +1,129 @@
+package kitchen
+
+import "time"
+
+// Stats is the outcome of the kitchen work
+type Stats struct {
+	// orders passed to the kitchen
+	Received int `json:"received"`
+	// orders placed on a shelf
+	Placed int `json:"placed"`
+	// orders the kitchen had no seat for
+	Rejected int `json:"rejected"`
+	// orders picked up by a courier
+	Delivered int `json:"delivered"`
+	// orders whose value dropped to zero on a shelf
+	Expired int `json:"expired"`
+	// orders thrown away to free up a seat
+	Discarded int `json:"discarded"`
+	// orders cancelled by customers
+	Cancelled int `json:"cancelled"`
+	// couriers that did not find their order
+	Missed int `json:"missed"`
+	// orders that are still on the shelves
+	OnShelves int `json:"onShelves"`
+	// sum of the inherent values of the orders at pickup
+	DeliveredValue float64 `json:"deliveredValue"`
+	// couriers that arrived at the kitchen
+	CouriersArrived int `json:"couriersArrived"`
+	// total time between courier requests and arrivals
+	CourierArrivalTotal time.Duration `json:"-"`
+	// the longest time between a courier request and arrival
+	CourierArrivalMax time.Duration `json:"-"`
+	// total time couriers waited for orders after arrival
+	CourierWaitTotal time.Duration `json:"-"`
+	// the longest time a courier waited for an order after arrival
+	CourierWaitMax time.Duration `json:"-"`
+	// total time delivered orders waited for couriers after placing
+	FoodWaitTotal time.Duration `json:"-"`
+	// the longest time a delivered order waited for a courier after placing
+	FoodWaitMax time.Duration `json:"-"`
+}
+
+// Report is the summary of the kitchen work
+type Report struct {
+	Stats
+	// average inherent value of the orders at pickup
+	AverageValue float64 `json:"averageValue"`
+	// average time between courier request and arrival (seconds)
+	AverageCourierArrival float64 `json:"averageCourierArrival"`
+	// the longest time between courier request and arrival (seconds)
+	MaxCourierArrival float64 `json:"maxCourierArrival"`
+	// average time a courier waited for an order after arrival (seconds)
+	AverageCourierWait float64 `json:"averageCourierWait"`
+	// the longest time a courier waited for an order after arrival (seconds)
+	MaxCourierWait float64 `json:"maxCourierWait"`
+	// average time an order waited for a courier after placing (seconds)
+	AverageFoodWait float64 `json:"averageFoodWait"`
+	// the longest time an order waited for a courier after placing (seconds)
+	MaxFoodWait float64 `json:"maxFoodWait"`
+	// occupancy of the shelves
+	Shelves []ShelfReport `json:"shelves"`
+}
+
+// ShelfReport is the summary of the shelf work
+type ShelfReport struct {
+	Name        string `json:"name"`
+	Temperature string `json:"temp"`
+	Capacity    int    `json:"cap"`
+	// max number of orders that were on the shelf at the same time
+	PeakOccupancy int `json:"peakOccupancy"`
+}
+
+// Stats returns the counters of the kitchen and its shelves
+func (k *Kitchen) Stats() Stats {
+	k.statsMutex.Lock()
+	stats := k.stats
+	k.statsMutex.Unlock()
+
+	for _, s := range k.AllShelves() {
+		s.mutex.Lock()
+		stats.Expired += s.expired
+		stats.Discarded += s.discarded
+		stats.OnShelves += len(s.orders)
+		s.mutex.Unlock()
+	}
+
+	return stats
+}
+
+// Report returns the summary of the kitchen work
+func (k *Kitchen) Report() Report {
+	report := Report{Stats: k.Stats()}
+
+	if report.Delivered > 0 {
+		report.AverageValue = report.DeliveredValue / float64(report.Delivered)
+	}
+
+	if report.CouriersArrived > 0 {
+		report.AverageCourierArrival = (report.CourierArrivalTotal / time.Duration(report.CouriersArrived)).Seconds()
+	}
+	report.MaxCourierArrival = report.CourierArrivalMax.Seconds()
+
+	if report.Delivered > 0 {
+		report.AverageCourierWait = (report.CourierWaitTotal / time.Duration(report.Delivered)).Seconds()
+		report.AverageFoodWait = (report.FoodWaitTotal / time.Duration(report.Delivered)).Seconds()
+	}
+	report.MaxCourierWait = report.CourierWaitMax.Seconds()
+	report.MaxFoodWait = report.FoodWaitMax.Seconds()
+
+	for _, s := range k.AllShelves() {
+		s.mutex.Lock()
+		report.Shelves = append(report.Shelves, ShelfReport{
+			Name:          s.Name,
+			Temperature:   s.Temperature,
+			Capacity:      s.Capacity,
+			PeakOccupancy: s.peak,
+		})
+		s.mutex.Unlock()
+	}
+
+	return report
+}
+
+// count updates the kitchen counters
+func (k *Kitchen) count(update func(stats *Stats)) {
+	k.statsMutex.Lock()
+	update(&k.stats)
+	k.statsMutex.Unlock()
+}
@@ -0,0 +1,138 @@
+package kitchen
+
+import "time"
+
+// OrderState is the stage of the order lifecycle
+type OrderState string
+
+// Order states
+const (
+	// the order is passed to the kitchen, but not placed yet
+	StateReceived OrderState = "received"
+	// the order is waiting for a courier on a shelf
+	StateOnShelf OrderState = "onShelf"
+	// the kitchen had no seat for the order
+	StateRejected OrderState = "rejected"
+	// the order is picked up by a courier
+	StateDelivered OrderState = "delivered"
+	// the value of the order dropped to zero
+	StateExpired OrderState = "expired"
+	// the order is thrown away to free up a seat
+	StateDiscarded OrderState = "discarded"
+	// the order is cancelled by the customer
+	StateCancelled OrderState = "cancelled"
+)
+
+// OrderStatus is the current state of the order
+type OrderStatus struct {
+	ID    string     `json:"id"`
+	State OrderState `json:"state"`
+	// name of the shelf the order is on, or was on before it left the kitchen
+	Shelf string `json:"shelf,omitempty"`
+	// time since the order was received (seconds)
+	Age float64 `json:"age"`
+	// current inherent value of the order, or the value when the order left the kitchen
+	Value float64 `json:"value"`
+	// expected arrival time of the courier
+	CourierETA *time.Time `json:"courierEta,omitempty"`
+	// time the order left the kitchen
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// orderRecord is the history of the order in the kitchen
+type orderRecord struct {
+	status     OrderStatus
+	receivedAt time.Time
+}
+
+// OrderStatus returns the current state of the order by given ID, the kitchen keeps the state of every order it has received
+func (k *Kitchen) OrderStatus(orderID string) (OrderStatus, bool) {
+	k.recordsMutex.Lock()
+	record, ok := k.records[orderID]
+	if !ok {
+		k.recordsMutex.Unlock()
+		return OrderStatus{}, false
+	}
+	status := record.status
+	receivedAt := record.receivedAt
+	k.recordsMutex.Unlock()
+
+	now := k.clock.Now()
+	if status.CompletedAt != nil {
+		now = *status.CompletedAt
+	} else if order, shelf, ok := k.FindOrder(orderID); ok {
+		status.Shelf = shelf.Name
+		status.Value = order.GetInherentValue(now)
+	}
+
+	status.Age = now.Sub(receivedAt).Seconds()
+
+	return status, true
+}
+
+// receive starts the history of the order, returns false if the kitchen has already received an order with the ID
+func (k *Kitchen) receive(orderID string) bool {
+	k.recordsMutex.Lock()
+	defer k.recordsMutex.Unlock()
+
+	if _, ok := k.records[orderID]; ok {
+		return false
+	}
+	k.records[orderID] = &orderRecord{status: OrderStatus{ID: orderID, State: StateReceived}, receivedAt: k.clock.Now()}
+
+	return true
+}
+
+// track updates the history of the order by the event
+func (k *Kitchen) track(event Event) {
+	k.recordsMutex.Lock()
+	defer k.recordsMutex.Unlock()
+
+	record, ok := k.records[event.OrderID]
+	if !ok {
+		if event.Type != OrderReceived {
+			return
+		}
+
+		record = &orderRecord{receivedAt: event.Time}
+		k.records[event.OrderID] = record
+	}
+
+	status := &record.status
+	status.ID = event.OrderID
+	if event.Shelf != "" {
+		status.Shelf = event.Shelf
+	}
+
+	switch event.Type {
+	case OrderReceived:
+		record.receivedAt = event.Time
+		status.State = StateReceived
+		status.Value = event.Value
+	case OrderPlaced, OrderMoved:
+		status.State = StateOnShelf
+		status.Value = event.Value
+	case CourierDispatched:
+		status.CourierETA = event.CourierETA
+	case OrderRejected:
+		status.State = StateRejected
+	case OrderPickedUp:
+		status.State = StateDelivered
+	case OrderExpired:
+		status.State = StateExpired
+	case OrderDiscarded:
+		status.State = StateDiscarded
+	case OrderCancelled:
+		status.State = StateCancelled
+	default:
+		return
+	}
+
+	switch event.Type {
+	case OrderRejected, OrderPickedUp, OrderExpired, OrderDiscarded, OrderCancelled:
+		completedAt := event.Time
+		status.CompletedAt = &completedAt
+		status.CourierETA = nil
+		status.Value = event.Value
+	}
+}
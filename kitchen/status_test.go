@@ -0,0 +1,87 @@
+package kitchen
+
+import (
+	"testing"
+	"time"
+
+	c "delivery/config"
+)
+
+func TestOrderStatus(t *testing.T) {
+	clock := NewVirtualClock(time.Now())
+
+	c.Config.Order.Age.Duration = time.Second
+	c.Config.Courier.Arrive.Duration = time.Second
+	c.Config.Courier.Arrive.Min = 4
+	c.Config.Courier.Arrive.Max = 4
+
+	hotShelf := NewShelf(clock, "Hot shelf", "hot", 10, 1)
+
+	k := New(
+		clock,
+		map[string]*Shelf{
+			"hot": hotShelf,
+		},
+		[]*Shelf{NewShelf(clock, "Overflow shelf", "any", 10, 2)},
+	)
+
+	delivered := &Order{ID: "1", Temperature: "hot", ShelfLife: 100, DecayRate: 1}
+	expired := &Order{ID: "2", Temperature: "hot", ShelfLife: 2, DecayRate: 1}
+
+	k.PlaceOrder(delivered)
+	k.CreateCourier(delivered)
+	k.PlaceOrder(expired)
+
+	t.Run("OnShelf", func(t *testing.T) {
+		clock.Advance(time.Second)
+
+		status, ok := k.OrderStatus(delivered.ID)
+		if !ok {
+			t.Fatalf("got %v want %v", ok, true)
+		}
+
+		if status.State != StateOnShelf || status.Shelf != "Hot shelf" {
+			t.Errorf("got %+v want %v", status, StateOnShelf)
+		}
+
+		if status.Age != 1 || status.Value != 0.99 {
+			t.Errorf("got %+v want %v", status, "age 1 and value 0.99")
+		}
+
+		if status.CourierETA == nil || status.CourierETA.Sub(clock.Now()) != 3*time.Second {
+			t.Errorf("got %v want %v", status.CourierETA, "courier in 3 seconds")
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		clock.Advance(time.Second)
+
+		status, ok := k.OrderStatus(expired.ID)
+		if !ok {
+			t.Fatalf("got %v want %v", ok, true)
+		}
+
+		if status.State != StateExpired || status.CompletedAt == nil || !status.CompletedAt.Equal(clock.Now()) {
+			t.Errorf("got %+v want %v", status, StateExpired)
+		}
+	})
+
+	t.Run("Delivered", func(t *testing.T) {
+		clock.Advance(5 * time.Second)
+
+		status, ok := k.OrderStatus(delivered.ID)
+		if !ok {
+			t.Fatalf("got %v want %v", ok, true)
+		}
+
+		if status.State != StateDelivered || status.CompletedAt == nil || status.Age != 4 || status.Value != 0.96 {
+			t.Errorf("got %+v want %v", status, StateDelivered)
+		}
+	})
+
+	t.Run("OrderStatus_Negative", func(t *testing.T) {
+		if _, ok := k.OrderStatus("3"); ok {
+			t.Errorf("got %v want %v", ok, false)
+		}
+	})
+}
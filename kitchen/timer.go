@@ -0,0 +1,78 @@
+package kitchen
+
+import "time"
+
+// kitchenTimer is the timer of the kitchen, the kitchen stops its timers on close
+type kitchenTimer struct {
+	kitchen *Kitchen
+	id      int
+	timer   Timer
+}
+
+// Stop prevents the timer from firing
+func (t *kitchenTimer) Stop() bool {
+	if !t.kitchen.forgetTimer(t.id) {
+		return false
+	}
+
+	return t.timer.Stop()
+}
+
+// afterFunc schedules f on the clock of the kitchen, nothing is scheduled once the kitchen is closed
+func (k *Kitchen) afterFunc(d time.Duration, f func()) Timer {
+	k.timersMutex.Lock()
+	if k.ctx.Err() != nil {
+		k.timersMutex.Unlock()
+		return stoppedTimer{}
+	}
+	k.lastTimerID++
+	id := k.lastTimerID
+	k.timers[id] = nil
+	k.timersMutex.Unlock()
+
+	timer := k.clock.AfterFunc(d, func() {
+		if k.forgetTimer(id) {
+			f()
+		}
+	})
+
+	k.timersMutex.Lock()
+	if _, ok := k.timers[id]; ok {
+		k.timers[id] = timer
+	}
+	k.timersMutex.Unlock()
+
+	return &kitchenTimer{kitchen: k, id: id, timer: timer}
+}
+
+// forgetTimer removes the timer from the kitchen, returns false if the timer has already fired or been stopped
+func (k *Kitchen) forgetTimer(id int) bool {
+	k.timersMutex.Lock()
+	defer k.timersMutex.Unlock()
+
+	_, ok := k.timers[id]
+	delete(k.timers, id)
+
+	return ok
+}
+
+// stopTimers stops all scheduled timers of the kitchen
+func (k *Kitchen) stopTimers() {
+	k.timersMutex.Lock()
+	defer k.timersMutex.Unlock()
+
+	for id, timer := range k.timers {
+		if timer != nil {
+			timer.Stop()
+		}
+		delete(k.timers, id)
+	}
+}
+
+// stoppedTimer is the timer that never fires
+type stoppedTimer struct{}
+
+// Stop returns false, the timer has never been scheduled
+func (stoppedTimer) Stop() bool {
+	return false
+}
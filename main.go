@@ -3,135 +3,658 @@ package main
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
+	"delivery/api"
 	c "delivery/config"
+	"delivery/dashboard"
 	"delivery/kitchen"
+	"delivery/metrics"
 )
 
+const (
+	// interval of the checks whether the kitchen is drained
+	pollInterval = 5 * time.Second
+	// interval of the checks for pending couriers during the shutdown
+	drainInterval = 100 * time.Millisecond
+	// time the HTTP API has to finish the requests in progress during the shutdown
+	shutdownTimeout = 5 * time.Second
+)
+
+// subcommands of the app, the delivery runs without a subcommand
+var commands = map[string]func(args []string) error{
+	"generate": generate,
+	"sweep":    sweep,
+	"config":   configCommand,
+}
+
 func main() {
-	ordersPath := flag.String("o", "", "Orders file path (required)")
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
+	ordersPath := flag.String("o", "", "Orders file path, '-' reads the orders from the stdin (required)")
+	follow := flag.Bool("follow", false, "Keep reading the orders file as it grows like 'tail -f' (realtime mode only)")
+	replayOrders := flag.Bool("replay", false, "Place the orders at their 'receivedAt' times instead of the ingestion rate")
+	speed := flag.Float64("speed", 1, "Speed of the replay, e.g. 2 replays the orders twice as fast")
 	configPath := flag.String("c", "config.yml", "Config file path")
+	mode := flag.String("mode", "realtime", "Run mode: 'realtime' or 'simulate'")
+	seed := flag.Int64("seed", 0, "Random seed, overrides the seed from the config")
+	reportPath := flag.String("report", "", "Path of the JSON report written at exit")
+	eventsPath := flag.String("events", "", "Path of the file the order lifecycle events are written to as JSON lines")
+	listen := flag.String("listen", "", "Address of the HTTP API, e.g. ':8080' (realtime mode only)")
+	metricsListen := flag.String("metrics", "", "Address of the Prometheus metrics served on '/metrics', e.g. ':9090' (realtime mode only)")
+	stateDir := flag.String("state-dir", "", "Directory of the kitchen journal and snapshots, the kitchen continues from the saved state on start (realtime mode only)")
+	snapshotInterval := flag.Duration("snapshot-interval", time.Minute, "Interval of the kitchen snapshots in the state directory")
+	tui := flag.Bool("tui", false, "Show the shelves dashboard instead of the logs (realtime mode only)")
 	flag.Parse()
 
-	if *ordersPath == "" {
+	if *ordersPath == "" && *listen == "" {
 		flag.PrintDefaults()
 		log.Fatal("Missing required arguments")
 	}
 
+	if *listen != "" && *mode != "realtime" {
+		log.Fatal("HTTP API is available in the realtime mode only")
+	}
+
+	if *metricsListen != "" && *mode != "realtime" {
+		log.Fatal("Metrics are available in the realtime mode only")
+	}
+
+	if *tui && *mode != "realtime" {
+		log.Fatal("Dashboard is available in the realtime mode only")
+	}
+
+	if *follow && *mode != "realtime" {
+		log.Fatal("Following the orders file is available in the realtime mode only")
+	}
+
+	if *stateDir != "" && *mode != "realtime" {
+		log.Fatal("Saving the kitchen state is available in the realtime mode only")
+	}
+
+	if *snapshotInterval <= 0 {
+		log.Fatal("Snapshot interval must be positive")
+	}
+
+	if *speed <= 0 {
+		log.Fatal("Replay speed must be positive")
+	}
+
 	err := c.Init(*configPath)
+	if problems, ok := err.(c.Errors); ok {
+		writeProblems(os.Stderr, *configPath, problems)
+		log.Fatalf("%s has %d problems", *configPath, len(problems))
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	orders, err := readOrders(*ordersPath)
+	pace := fixedRate(c.Config)
+	if *replayOrders {
+		pace = newReplay(c.Config, *speed).pace
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			c.Config.Seed = seed
+		}
+	})
+
+	if c.Config.Seed == nil {
+		randomSeed := time.Now().UnixNano()
+		c.Config.Seed = &randomSeed
+	}
+
+	log.Infof("Random seed: %d", *c.Config.Seed)
+
+	if *tui {
+		log.SetOutput(ioutil.Discard)
+		kitchen.SetLogOutput(ioutil.Discard)
+	}
+
+	var clock kitchen.Clock
+	switch *mode {
+	case "realtime":
+		clock = kitchen.RealClock{}
+	case "simulate":
+		clock = kitchen.NewVirtualClock(time.Now())
+	default:
+		flag.PrintDefaults()
+		log.Fatalf("Unknown mode '%s'", *mode)
+	}
+
+	var journal *kitchen.Journal
+	var saved *kitchen.State
+	var options []kitchen.Option
+	if *stateDir != "" {
+		journal, saved, err = kitchen.OpenJournal(*stateDir)
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "Cannot open kitchen state"))
+		}
+		defer journal.Close()
+
+		options = append(options, kitchen.WithJournal(journal))
+	}
+
+	k, err := createKitchenFromConfig(c.Config, clock, *c.Config.Seed, options...)
 	if err != nil {
-		log.Fatal(errors.Wrap(err, "Cannot read orders"))
+		log.Fatal(err)
+	}
+
+	if *eventsPath != "" {
+		file, err := os.Create(*eventsPath)
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "Cannot create events file"))
+		}
+		defer file.Close()
+
+		k.Subscribe(kitchen.WriteEvents(file))
 	}
 
-	log.Infof("%d orders have been read", len(orders))
+	if saved != nil {
+		if err := k.Restore(*saved); err != nil {
+			log.Fatal(errors.Wrap(err, "Cannot restore kitchen state"))
+		}
 
-	k := kitchen.New(
-		createShelvesFromConfig(),
-		createOverflowShelfFromConfig(),
-	)
+		log.Infof("%d orders and %d couriers have been restored", len(saved.Orders), len(saved.Couriers))
+	}
 
-	command := make(chan string)
+	finish := func(report kitchen.Report) {
+		printReport(os.Stdout, report)
 
-	go func() {
-		log.Info("Start delivery...")
-
-		for {
-			select {
-			case cmd := <-command:
-				fmt.Println(cmd)
-				switch cmd {
-				case "p":
-					log.Warning("PAUSED")
-					k.Pause()
-				case "c":
-					log.Warning("CONTINUE")
-					k.Unpause()
-				}
-			default:
-				if k.IsOnPause() {
-					continue
-				} else if len(orders) == 0 {
-					return
-				}
-
-				time.Sleep(c.Config.Order.IngestionRate.Duration / time.Duration(c.Config.Order.IngestionRate.Count))
-
-				var order *kitchen.Order
-				order, orders = orders[0], orders[1:]
-
-				log.Infof("Order received: %s", order.ID)
-
-				if k.PlaceOrder(order) {
-					go k.CreateCourier(order)
-				}
+		if *reportPath != "" {
+			if err := writeReport(*reportPath, report); err != nil {
+				log.Fatal(errors.Wrap(err, "Cannot write report"))
 			}
 		}
+	}
+
+	switch clock := clock.(type) {
+	case *kitchen.VirtualClock:
+		orders, err := readOrders(*ordersPath)
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "Cannot read orders"))
+		}
+
+		log.Infof("%d orders have been read", len(orders))
+
+		finish(simulate(clock, k, orders, pace, os.Stdout))
+	default:
+		ctx, stop := notifyShutdown()
+		defer stop()
+
+		// the orders are placed as they are read, so the kitchen can be piped behind other tools
+		var orders *orderStream
+		if *ordersPath != "" {
+			var file io.Closer
+			orders, file, err = openOrders(ctx, *ordersPath, *follow)
+			if err != nil {
+				log.Fatal(errors.Wrap(err, "Cannot read orders"))
+			}
+			defer file.Close()
+
+			// the orders taken from the file before the restart are not placed again, the orders of the HTTP API are not counted
+			if saved != nil {
+				skipped := skipOrders(orders, pace, saved.Cursor)
+				log.Infof("%d orders have already been received", skipped)
+			}
+		}
+
+		if journal != nil {
+			// the restored state is saved at once, so the journal entries written before the restart are not replayed again
+			if err := journal.Snapshot(k); err != nil {
+				log.Fatal(errors.Wrap(err, "Cannot save kitchen state"))
+			}
+			go snapshots(ctx, journal, k, *snapshotInterval)
+		}
+
+		var servers []*http.Server
+		var apiServer *http.Server
+		if *listen != "" {
+			apiServer = listenAndServe(*listen, api.NewServer(k), "HTTP API")
+			servers = append(servers, apiServer)
+		}
+
+		if *metricsListen != "" {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", metrics.New(k))
+			servers = append(servers, listenAndServe(*metricsListen, mux, "Metrics"))
+		}
+
+		var board *dashboard.Dashboard
+		if *tui {
+			board = dashboard.New(clock, k)
+			board.Run(os.Stdout, c.Config.Order.Age.Duration)
+		}
+
+		// the kitchen with the HTTP API keeps waiting for new orders until it is stopped
+		drained := realtime(ctx, clock, k, orders, pace, *listen == "", *ordersPath != "-")
+
+		select {
+		case <-drained:
+		case <-ctx.Done():
+			log.Warning("Shutting down...")
+		}
+
+		// the HTTP API stops first, so no orders are placed while the couriers are drained.
+		// The couriers on their way are waited for on both paths, so they are in the report as delivered or missed
+		if apiServer != nil {
+			shutdown(apiServer)
+		}
+		drain(k, time.Duration(c.Config.Courier.Arrive.Max)*c.Config.Courier.Arrive.Duration)
+
+		for _, server := range servers {
+			shutdown(server)
+		}
+
+		if journal != nil {
+			if err := journal.Snapshot(k); err != nil {
+				log.Error(errors.Wrap(err, "Cannot save kitchen state"))
+			}
+		}
+
+		k.Close()
+		// the dashboard would clear the report otherwise
+		if board != nil {
+			board.Stop()
+		}
+		finish(k.Report())
+	}
+}
+
+// listenAndServe starts the HTTP server in the background
+func listenAndServe(addr string, handler http.Handler, name string) *http.Server {
+	server := &http.Server{Addr: addr, Handler: handler}
+
+	go func() {
+		log.Infof("%s is listening on %s", name, addr)
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
 	}()
 
+	return server
+}
+
+// shutdown stops the HTTP server, waiting for the requests in progress no longer than the shutdown timeout
+func shutdown(server *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	server.Shutdown(ctx)
+}
+
+// notifyShutdown returns the context that is done on SIGINT or SIGTERM
+func notifyShutdown() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
 	go func() {
-		for {
-			time.Sleep(5 * time.Second)
-			if k.IsEmpty() && !k.IsOnPause() {
-				os.Exit(0)
-			}
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
 		}
+		signal.Stop(signals)
 	}()
 
+	return ctx, cancel
+}
+
+// snapshots saves the state of the kitchen at the interval until the context is done
+func snapshots(ctx context.Context, journal *kitchen.Journal, k *kitchen.Kitchen, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := journal.Snapshot(k); err != nil {
+				log.Error(errors.Wrap(err, "Cannot save kitchen state"))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// drain waits for the couriers that are on their way to the kitchen, but no longer than the timeout
+func drain(k *kitchen.Kitchen, timeout time.Duration) {
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(drainInterval)
+	defer ticker.Stop()
+
+	for k.PendingCouriers() > 0 {
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			log.Warningf("%d couriers have not arrived in %s", k.PendingCouriers(), timeout)
+			return
+		}
+	}
+}
+
+// realtime runs the delivery on the wall clock, the returned channel is closed once the kitchen is empty if the delivery stops when drained.
+// The delivery can be paused from the stdin unless the orders are read from it
+func realtime(ctx context.Context, clock kitchen.Clock, k *kitchen.Kitchen, orders *orderStream, pace pacer, stopWhenDrained, commands bool) <-chan struct{} {
+	log.Info("Start delivery...")
+
+	drained := make(chan struct{})
+	ingest(ctx, clock, k, orders, pace, func() {
+		if stopWhenDrained {
+			close(drained)
+		}
+	})
+
+	if commands {
+		go readCommands(k)
+	} else {
+		log.Warning("Orders are read from the stdin, the commands are disabled")
+	}
+
+	return drained
+}
+
+// readCommands controls the kitchen by the commands from the stdin
+func readCommands(k *kitchen.Kitchen) {
 	scanner := bufio.NewScanner(os.Stdin)
 	for scanner.Scan() {
 		cmd := scanner.Text()
-		if cmd != "p" && cmd != "c" {
-			if cmd != "" {
-				log.Warning("Wrong command")
+
+		if orderID := strings.TrimPrefix(cmd, "cancel "); orderID != cmd {
+			fmt.Println(cmd)
+			if !k.CancelOrder(strings.TrimSpace(orderID)) {
+				log.Warningf("Order cannot be cancelled: %s", orderID)
 			}
 			continue
 		}
 
-		command <- cmd
+		switch cmd {
+		case "p":
+			fmt.Println(cmd)
+			log.Warning("PAUSED")
+			k.Pause()
+		case "c":
+			fmt.Println(cmd)
+			log.Warning("CONTINUE")
+			k.Unpause()
+		case "":
+		default:
+			log.Warning("Wrong command")
+		}
+	}
+}
+
+// run places orders on the kitchen at the pace and calls done once all of them have been placed and the kitchen is empty.
+// The ingestion stops when the context is done
+func run(ctx context.Context, clock kitchen.Clock, k *kitchen.Kitchen, orders []*kitchen.Order, pace pacer, done func()) {
+	// the kitchen is paused only in the realtime mode, which runs with the global config
+	interval := ingestionInterval(c.Config)
+
+	var ingested int32
+	if len(orders) == 0 {
+		ingested = 1
+	}
+
+	var ingest func()
+	ingest = func() {
+		if ctx.Err() != nil {
+			log.Warningf("Ingestion stopped, %d orders are not placed", len(orders))
+			atomic.StoreInt32(&ingested, 1)
+			return
+		}
+
+		// the order waits for the kitchen to continue
+		if k.IsOnPause() {
+			clock.AfterFunc(interval, ingest)
+			return
+		}
+
+		var order *kitchen.Order
+		order, orders = orders[0], orders[1:]
+
+		placeOrder(k, order)
+
+		if len(orders) == 0 {
+			atomic.StoreInt32(&ingested, 1)
+			return
+		}
+
+		clock.AfterFunc(pace(orders[0]), ingest)
+	}
+
+	if len(orders) > 0 {
+		clock.AfterFunc(pace(orders[0]), ingest)
+	}
+	pollDrained(clock, k, &ingested, done)
+}
+
+// placeOrder places the order taken from the orders file on the kitchen and creates a courier for it
+func placeOrder(k *kitchen.Kitchen, order *kitchen.Order) {
+	log.Infof("Order received: %s", order.ID)
+
+	k.AdvanceCursor()
+	if k.PlaceOrder(order) {
+		k.CreateCourier(order)
+	}
+}
+
+// pollDrained calls done once all orders have been ingested and the kitchen is empty,
+// or no courier of the fleet will ever come for the orders left on the shelves
+func pollDrained(clock kitchen.Clock, k *kitchen.Kitchen, ingested *int32, done func()) {
+	var poll func()
+	poll = func() {
+		if atomic.LoadInt32(ingested) == 1 && !k.IsOnPause() && (k.IsEmpty() || k.IsStranded()) {
+			if !k.IsEmpty() {
+				log.Warningf("%d orders are left on the shelves, the shifts of the fleet are over", k.Stats().OnShelves)
+			}
+			done()
+			return
+		}
+
+		clock.AfterFunc(pollInterval, poll)
 	}
+
+	clock.AfterFunc(pollInterval, poll)
+}
+
+// simulate replays the delivery on the virtual clock as fast as possible and returns the report once there is nothing left to do
+func simulate(clock *kitchen.VirtualClock, k *kitchen.Kitchen, orders []*kitchen.Order, pace pacer, w io.Writer) kitchen.Report {
+	start := clock.Now()
+
+	log.Info("Start simulation...")
+
+	run(context.Background(), clock, k, orders, pace, func() {})
+	clock.Run()
+
+	fmt.Fprintf(w, "Simulated time: %s\n", clock.Now().Sub(start))
+
+	return k.Report()
 }
 
+// readOrders reads all orders from the file or from the stdin if the path is '-'
 func readOrders(path string) ([]*kitchen.Order, error) {
-	file, err := ioutil.ReadFile(path)
+	orders, file, err := openOrders(context.Background(), path, false)
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 
 	var result []*kitchen.Order
+	for {
+		order, err := orders.Next()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, order)
+	}
+}
+
+// createKitchenFromConfig creates the kitchen described by the config with the extra options
+func createKitchenFromConfig(config *c.DeliveryConfig, clock kitchen.Clock, seed int64, extra ...kitchen.Option) (*kitchen.Kitchen, error) {
+	overflowShelves, err := createOverflowShelvesFromConfig(config, clock)
+	if err != nil {
+		return nil, err
+	}
+
+	dispatch, err := kitchen.NewDispatchStrategy(config.Courier.Dispatch)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []kitchen.Option{
+		kitchen.WithRand(kitchen.NewRand(seed)),
+		kitchen.WithDispatchStrategy(dispatch),
+		kitchen.WithCourierArrival(kitchen.CourierArrival{
+			Min:  config.Courier.Arrive.Min,
+			Max:  config.Courier.Arrive.Max,
+			Unit: config.Courier.Arrive.Duration,
+		}),
+		kitchen.WithAgeUnit(config.Order.Age.Duration),
+	}
+	if fleet := createFleetFromConfig(config); fleet != nil {
+		options = append(options, kitchen.WithFleet(fleet))
+	}
+	if len(config.Compatibility) > 0 {
+		options = append(options, kitchen.WithCompatibility(createCompatibilityFromConfig(config)))
+	}
+	options = append(options, extra...)
+
+	return kitchen.New(
+		clock,
+		createShelvesFromConfig(config, clock),
+		overflowShelves,
+		options...,
+	), nil
+}
+
+// createFleetFromConfig returns nil when the fleet is not configured, so every order gets its own courier
+func createFleetFromConfig(config *c.DeliveryConfig) *kitchen.Fleet {
+	fleetData := config.Courier.Fleet
+	if fleetData.Size == 0 && len(fleetData.Shifts) == 0 {
+		return nil
+	}
+
+	fleet := &kitchen.Fleet{
+		Size:     fleetData.Size,
+		Capacity: fleetData.Capacity,
+	}
+	for _, shiftData := range fleetData.Shifts {
+		size := shiftData.Size
+		if size == 0 {
+			size = fleetData.Size
+		}
+
+		fleet.Shifts = append(fleet.Shifts, kitchen.Shift{
+			Start: shiftData.StartDuration,
+			End:   shiftData.EndDuration,
+			Size:  size,
+		})
+	}
+
+	return fleet
+}
 
-	err = json.Unmarshal([]byte(file), &result)
+func createCompatibilityFromConfig(config *c.DeliveryConfig) kitchen.Compatibility {
+	compatibility := make(kitchen.Compatibility, len(config.Compatibility))
+	for _, compatibilityData := range config.Compatibility {
+		for _, shelfData := range compatibilityData.Shelves {
+			compatibility[compatibilityData.Temperature] = append(compatibility[compatibilityData.Temperature], kitchen.CompatibleShelf{
+				Temperature:     shelfData.Temperature,
+				Name:            shelfData.Name,
+				DecayMultiplier: shelfData.DecayMultiplier,
+			})
+		}
+	}
 
-	return result, err
+	return compatibility
 }
 
-func createShelvesFromConfig() map[string]*kitchen.Shelf {
-	shelves := make(map[string]*kitchen.Shelf, len(c.Config.Shelves))
-	for _, shelfData := range c.Config.Shelves {
-		shelves[shelfData.Temperature] = kitchen.NewShelf(shelfData.Name, shelfData.Temperature, shelfData.Capacity, shelfData.DecayModifier)
+func createShelvesFromConfig(config *c.DeliveryConfig, clock kitchen.Clock) map[string]*kitchen.Shelf {
+	shelves := make(map[string]*kitchen.Shelf, len(config.Shelves))
+	for _, shelfData := range config.Shelves {
+		shelves[shelfData.Temperature] = kitchen.NewShelf(clock, shelfData.Name, shelfData.Temperature, shelfData.Capacity, shelfData.DecayModifier)
 	}
 
 	return shelves
 }
 
-func createOverflowShelfFromConfig() *kitchen.Shelf {
+// createOverflowShelvesFromConfig returns the overflow shelves in priority order, the single 'overflowShelf' is used when there is no 'overflowShelves' list
+func createOverflowShelvesFromConfig(config *c.DeliveryConfig, clock kitchen.Clock) ([]*kitchen.Shelf, error) {
+	if len(config.OverflowShelves) == 0 {
+		overflowShelf, err := createOverflowShelfFromConfig(config, clock)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*kitchen.Shelf{overflowShelf}, nil
+	}
+
+	overflowShelves := make([]*kitchen.Shelf, 0, len(config.OverflowShelves))
+	for _, shelfData := range config.OverflowShelves {
+		discardPolicy, err := kitchen.NewDiscardPolicy(shelfData.DiscardPolicy)
+		if err != nil {
+			return nil, err
+		}
+
+		temp := "any"
+		if len(shelfData.Temperatures) > 0 {
+			temp = strings.Join(shelfData.Temperatures, ",")
+		}
+
+		overflowShelves = append(overflowShelves, kitchen.NewShelf(
+			clock,
+			shelfData.Name,
+			temp,
+			shelfData.Capacity,
+			shelfData.DecayModifier,
+			kitchen.WithDiscardPolicy(discardPolicy),
+			kitchen.WithAcceptedTemperatures(shelfData.Temperatures...),
+		))
+	}
+
+	return overflowShelves, nil
+}
+
+func createOverflowShelfFromConfig(config *c.DeliveryConfig, clock kitchen.Clock) (*kitchen.Shelf, error) {
+	discardPolicy, err := kitchen.NewDiscardPolicy(config.OverflowShelf.DiscardPolicy)
+	if err != nil {
+		return nil, err
+	}
+
 	return kitchen.NewShelf(
-		c.Config.OverflowShelf.Name,
-		c.Config.OverflowShelf.Temperature,
-		c.Config.OverflowShelf.Capacity,
-		c.Config.OverflowShelf.DecayModifier,
-	)
+		clock,
+		config.OverflowShelf.Name,
+		config.OverflowShelf.Temperature,
+		config.OverflowShelf.Capacity,
+		config.OverflowShelf.DecayModifier,
+		kitchen.WithDiscardPolicy(discardPolicy),
+	), nil
 }
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	c "delivery/config"
+	"delivery/kitchen"
 )
 
 func init() {
@@ -24,12 +31,49 @@ func TestReadOrders(t *testing.T) {
 	}
 }
 
+func TestReadOrders_Decay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "delivery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		name     string
+		contents string
+		wantErr  bool
+	}{
+		{"exponential", `[{"id": "1", "temp": "frozen", "shelfLife": 300, "decayRate": 0.5, "decay": {"model": "exponential", "minValue": 0.2}}]`, false},
+		{"piecewise", `[{"id": "1", "temp": "hot", "shelfLife": 300, "decayRate": 0.5, "decay": {"model": "piecewise", "points": [{"age": 10, "value": 0.9}, {"age": 60, "value": 0}]}}]`, false},
+		{"step_Negative", `[{"id": "1", "temp": "hot", "shelfLife": 300, "decayRate": 0.5, "decay": {"model": "step"}}]`, true},
+		{"unknown_Negative", `[{"id": "1", "temp": "hot", "shelfLife": 300, "decayRate": 0.5, "decay": {"model": "cubic"}}]`, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.name+".json")
+			if err := ioutil.WriteFile(path, []byte(tc.contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			orders, err := readOrders(path)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got %v want error %v", err, tc.wantErr)
+			}
+
+			if !tc.wantErr && (len(orders) != 1 || orders[0].Decay == nil || orders[0].Decay.Model != tc.name) {
+				t.Errorf("got %+v want %v", orders, tc.name)
+			}
+		})
+	}
+}
+
 func TestCreateShelvesFromConfig(t *testing.T) {
 	c.Config.Shelves = []struct {
 		Name          string `yaml:"name"`
 		Temperature   string `yaml:"temp"`
 		Capacity      int    `yaml:"cap"`
-		DecayModifier int    `yaml:"decayModifier​"`
+		DecayModifier int    `yaml:"decayModifier"`
 	}{{
 		Name:          "Frozen shelf",
 		Temperature:   "frozen",
@@ -48,9 +92,205 @@ func TestCreateShelvesFromConfig(t *testing.T) {
 	}}
 
 	want := len(c.Config.Shelves)
-	got := len(createShelvesFromConfig())
+	got := len(createShelvesFromConfig(c.Config, kitchen.RealClock{}))
 
 	if got != want {
 		t.Errorf("got %d want %d", got, want)
 	}
 }
+
+func TestRun(t *testing.T) {
+	orders, err := readOrders("orders_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := kitchen.NewVirtualClock(time.Now())
+	k, err := createKitchenFromConfig(c.Config, clock, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := false
+	run(context.Background(), clock, k, orders, fixedRate(c.Config), func() {
+		done = true
+	})
+
+	for i := 0; i < 3600 && !done; i++ {
+		clock.Advance(time.Second)
+	}
+
+	if !done {
+		t.Errorf("got %v want %v", done, true)
+	}
+
+	if !k.IsEmpty() {
+		t.Errorf("got %v want %v", k.IsEmpty(), true)
+	}
+}
+
+func TestRun_Stopped(t *testing.T) {
+	orders, err := readOrders("orders_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := kitchen.NewVirtualClock(time.Now())
+	k, err := createKitchenFromConfig(c.Config, clock, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := false
+	run(ctx, clock, k, orders, fixedRate(c.Config), func() {
+		done = true
+	})
+	clock.Run()
+
+	if !done {
+		t.Errorf("got %v want %v", done, true)
+	}
+
+	if received := k.Stats().Received; received != 0 {
+		t.Errorf("got %v want %v", received, 0)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	orders, err := readOrders("orders_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := simulateOrders(orders, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Received != len(orders) {
+		t.Errorf("got %v want %v", report.Received, len(orders))
+	}
+
+	if report.Delivered+report.Missed != report.Placed {
+		t.Errorf("got %v want %v", report.Delivered+report.Missed, report.Placed)
+	}
+
+	if report.OnShelves != 0 {
+		t.Errorf("got %v want %v", report.OnShelves, 0)
+	}
+}
+
+func TestSimulate_Seed(t *testing.T) {
+	orders, err := readOrders("orders_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := simulateOrders(orders, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orders, err = readOrders("orders_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := simulateOrders(orders, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Stats != want.Stats {
+		t.Errorf("got %+v want %+v", got.Stats, want.Stats)
+	}
+}
+
+func TestSimulate_ShiftsOver(t *testing.T) {
+	config, err := c.Parse([]byte(`
+order:
+  ingestionRate: {count: 2, time: 1s}
+  age: {time: 1s}
+courier:
+  arrive: {time: 1s, min: 2, max: 6}
+  fleet:
+    capacity: 1
+    shifts:
+      - {start: 0s, end: 10s, size: 1}
+shelves:
+  - {name: Hot shelf, temp: hot, cap: 10, decayModifier: 0}
+overflowShelf: {name: Overflow shelf, temp: any, cap: 15, decayModifier: 0}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var orders []*kitchen.Order
+	for i := 0; i < 10; i++ {
+		orders = append(orders, &kitchen.Order{ID: string(rune('a' + i)), Temperature: "hot", ShelfLife: 100, DecayRate: 1})
+	}
+
+	clock := kitchen.NewVirtualClock(time.Now())
+	k, err := createKitchenFromConfig(config, clock, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reports := make(chan kitchen.Report, 1)
+	go func() {
+		reports <- simulate(clock, k, orders, fixedRate(config), ioutil.Discard)
+	}()
+
+	select {
+	case report := <-reports:
+		// the orders that never decay wait on the shelves after the only shift is over
+		if report.OnShelves == 0 || report.Delivered+report.OnShelves != len(orders) {
+			t.Errorf("got %+v want %v", report.Stats, "delivered and stranded orders")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("got %v want %v", "hang", "report")
+	}
+}
+
+func simulateOrders(orders []*kitchen.Order, seed int64) (kitchen.Report, error) {
+	clock := kitchen.NewVirtualClock(time.Now())
+
+	k, err := createKitchenFromConfig(c.Config, clock, seed)
+	if err != nil {
+		return kitchen.Report{}, err
+	}
+
+	return simulate(clock, k, orders, fixedRate(c.Config), ioutil.Discard), nil
+}
+
+func TestWriteReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "delivery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "report.json")
+	want := kitchen.Report{Stats: kitchen.Stats{Delivered: 3}, AverageValue: 0.5}
+
+	if err := writeReport(path, want); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got kitchen.Report
+	if err := json.Unmarshal(contents, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Delivered != want.Delivered || got.AverageValue != want.AverageValue {
+		t.Errorf("got %+v want %+v", got, want)
+	}
+}
@@ -0,0 +1,169 @@
+// Package metrics exposes the kitchen in the Prometheus text format, so a long-lived kitchen can be scraped and alerted on
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"delivery/kitchen"
+)
+
+// contentType of the Prometheus text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultArrivalBuckets are the upper bounds (seconds) of the courier arrival histogram buckets
+var DefaultArrivalBuckets = []float64{1, 2, 5, 10, 15, 20, 30, 45, 60, 90, 120, 300}
+
+// counted are the order lifecycle events counted by type, in the order they are written
+var counted = []kitchen.EventType{
+	kitchen.OrderReceived,
+	kitchen.OrderPlaced,
+	kitchen.OrderRejected,
+	kitchen.OrderMoved,
+	kitchen.OrderExpired,
+	kitchen.OrderDiscarded,
+	kitchen.OrderPickedUp,
+	kitchen.OrderCancelled,
+	kitchen.CourierMissed,
+}
+
+// Metrics collects the kitchen metrics from the order lifecycle events, which are emitted where the kitchen logs
+type Metrics struct {
+	kitchen *kitchen.Kitchen
+	events  map[kitchen.EventType]int
+	arrival *histogram
+	mutex   sync.Mutex
+}
+
+// New creates the metrics of the kitchen and subscribes them to the kitchen events
+func New(k *kitchen.Kitchen) *Metrics {
+	m := &Metrics{
+		kitchen: k,
+		events:  make(map[kitchen.EventType]int, len(counted)),
+		arrival: newHistogram(DefaultArrivalBuckets),
+	}
+
+	k.Subscribe(m.handle)
+
+	return m
+}
+
+// handle counts the event
+func (m *Metrics) handle(event kitchen.Event) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.events[event.Type]++
+	if event.Type == kitchen.CourierArrived {
+		m.arrival.observe(event.CourierArrival)
+	}
+}
+
+// ServeHTTP writes the metrics for the Prometheus scrape
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	m.Write(w)
+}
+
+// Write writes the metrics in the Prometheus text format, the shelves occupancy is taken at the time of the call
+func (m *Metrics) Write(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+
+	shelves := m.kitchen.AllShelves()
+
+	fmt.Fprintln(buf, "# HELP delivery_shelf_orders Number of orders on the shelf.")
+	fmt.Fprintln(buf, "# TYPE delivery_shelf_orders gauge")
+	for _, shelf := range shelves {
+		fmt.Fprintf(buf, "delivery_shelf_orders{shelf=%s} %d\n", quote(shelf.Name), shelf.OrdersCount())
+	}
+
+	fmt.Fprintln(buf, "# HELP delivery_shelf_capacity Number of seats on the shelf.")
+	fmt.Fprintln(buf, "# TYPE delivery_shelf_capacity gauge")
+	for _, shelf := range shelves {
+		fmt.Fprintf(buf, "delivery_shelf_capacity{shelf=%s} %d\n", quote(shelf.Name), shelf.Capacity)
+	}
+
+	fmt.Fprintln(buf, "# HELP delivery_pending_couriers Number of couriers on their way to the kitchen.")
+	fmt.Fprintln(buf, "# TYPE delivery_pending_couriers gauge")
+	fmt.Fprintf(buf, "delivery_pending_couriers %d\n", m.kitchen.PendingCouriers())
+
+	fmt.Fprintln(buf, "# HELP delivery_waiting_couriers Number of arrived couriers waiting for an order.")
+	fmt.Fprintln(buf, "# TYPE delivery_waiting_couriers gauge")
+	fmt.Fprintf(buf, "delivery_waiting_couriers %d\n", m.kitchen.WaitingCouriers())
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	fmt.Fprintln(buf, "# HELP delivery_orders_total Number of the order lifecycle events by type.")
+	fmt.Fprintln(buf, "# TYPE delivery_orders_total counter")
+	for _, eventType := range counted {
+		fmt.Fprintf(buf, "delivery_orders_total{event=%s} %d\n", quote(string(eventType)), m.events[eventType])
+	}
+
+	fmt.Fprintln(buf, "# HELP delivery_courier_arrival_seconds Time couriers need to get to the kitchen.")
+	fmt.Fprintln(buf, "# TYPE delivery_courier_arrival_seconds histogram")
+	m.arrival.write(buf, "delivery_courier_arrival_seconds")
+
+	return buf.Flush()
+}
+
+// histogram counts the observations by cumulative buckets
+type histogram struct {
+	bounds []float64
+	counts []int
+	count  int
+	sum    float64
+}
+
+// newHistogram creates the histogram with the bucket upper bounds
+func newHistogram(bounds []float64) *histogram {
+	sorted := append([]float64(nil), bounds...)
+	sort.Float64s(sorted)
+
+	return &histogram{
+		bounds: sorted,
+		counts: make([]int, len(sorted)),
+	}
+}
+
+// observe adds the value to the buckets it falls into
+func (h *histogram) observe(value float64) {
+	for i, bound := range h.bounds {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+// write writes the buckets, the sum and the count of the histogram
+func (h *histogram) write(w io.Writer, name string) {
+	for i, bound := range h.bounds {
+		fmt.Fprintf(w, "%s_bucket{le=%s} %d\n", name, quote(formatFloat(bound)), h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n", name, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count %d\n", name, h.count)
+}
+
+// quote returns the label value escaped by the rules of the text format
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+// formatFloat formats the number without the exponent for the common values
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
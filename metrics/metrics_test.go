@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	c "delivery/config"
+	"delivery/kitchen"
+)
+
+func init() {
+	c.Init("../config.yml")
+}
+
+func TestMetrics(t *testing.T) {
+	clock := kitchen.NewVirtualClock(time.Now())
+
+	k := kitchen.New(
+		clock,
+		map[string]*kitchen.Shelf{
+			"hot": kitchen.NewShelf(clock, "Hot shelf", "hot", 1, 1),
+		},
+		[]*kitchen.Shelf{kitchen.NewShelf(clock, "Overflow shelf", "any", 1, 2)},
+		kitchen.WithCourierArrival(kitchen.CourierArrival{Min: 3, Max: 3, Unit: time.Second}),
+	)
+
+	server := httptest.NewServer(New(k))
+	defer server.Close()
+
+	for _, id := range []string{"1", "2", "3"} {
+		order := &kitchen.Order{ID: id, Temperature: "hot", ShelfLife: 300, DecayRate: 0.5}
+		if k.PlaceOrder(order) {
+			k.CreateCourier(order)
+		}
+	}
+	k.PlaceOrder(&kitchen.Order{ID: "4", Temperature: "cold"})
+
+	scrape := func(t *testing.T) string {
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		if got := resp.Header.Get("Content-Type"); got != contentType {
+			t.Errorf("got %v want %v", got, contentType)
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(body)
+	}
+
+	cases := []struct {
+		name    string
+		advance time.Duration
+		want    []string
+	}{
+		{"Placed", 0, []string{
+			`delivery_shelf_orders{shelf="Hot shelf"} 1`,
+			`delivery_shelf_capacity{shelf="Hot shelf"} 1`,
+			`delivery_shelf_orders{shelf="Overflow shelf"} 1`,
+			`delivery_pending_couriers 3`,
+			`delivery_waiting_couriers 0`,
+			`delivery_orders_total{event="placed"} 3`,
+			`delivery_orders_total{event="discarded"} 1`,
+			`delivery_orders_total{event="rejected"} 1`,
+			`delivery_courier_arrival_seconds_count 0`,
+		}},
+		{"Delivered", 3 * time.Second, []string{
+			`delivery_shelf_orders{shelf="Hot shelf"} 0`,
+			`delivery_pending_couriers 0`,
+			`delivery_orders_total{event="pickedUp"} 2`,
+			`delivery_orders_total{event="missed"} 1`,
+			`delivery_courier_arrival_seconds_bucket{le="2"} 0`,
+			`delivery_courier_arrival_seconds_bucket{le="5"} 3`,
+			`delivery_courier_arrival_seconds_bucket{le="+Inf"} 3`,
+			`delivery_courier_arrival_seconds_sum 9`,
+			`delivery_courier_arrival_seconds_count 3`,
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock.Advance(tc.advance)
+
+			got := scrape(t)
+			for _, want := range tc.want {
+				if !strings.Contains(got, want+"\n") {
+					t.Errorf("got %v want %v", got, want)
+				}
+			}
+		})
+	}
+
+	t.Run("Method_Negative", func(t *testing.T) {
+		resp, err := http.Post(server.URL, "text/plain", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("got %v want %v", resp.StatusCode, http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func TestQuote(t *testing.T) {
+	if got, want := quote("a \"b\"\\\n"), `"a \"b\"\\\n"`; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
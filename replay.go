@@ -0,0 +1,69 @@
+package main
+
+import (
+	"time"
+
+	c "delivery/config"
+	"delivery/kitchen"
+)
+
+// pacer returns how long to wait after the previous order before placing the order
+type pacer func(order *kitchen.Order) time.Duration
+
+// ingestionInterval returns the interval between orders at the ingestion rate of the config
+func ingestionInterval(config *c.DeliveryConfig) time.Duration {
+	return config.Order.IngestionRate.Duration / time.Duration(config.Order.IngestionRate.Count)
+}
+
+// fixedRate places the orders at the ingestion rate of the config
+func fixedRate(config *c.DeliveryConfig) pacer {
+	interval := ingestionInterval(config)
+
+	return func(*kitchen.Order) time.Duration {
+		return interval
+	}
+}
+
+// replay places the orders at the times they were received, the gaps between orders are divided by the speed.
+// Absolute times are counted from the first of them, an order without the time is received at the ingestion rate after the previous one
+type replay struct {
+	speed    float64
+	interval time.Duration
+	// first absolute time of the orders
+	start time.Time
+	// offset of the previous order
+	offset time.Duration
+}
+
+// newReplay creates the replay of the orders at the speed, orders without the time follow at the ingestion rate of the config
+func newReplay(config *c.DeliveryConfig, speed float64) *replay {
+	return &replay{
+		speed:    speed,
+		interval: ingestionInterval(config),
+	}
+}
+
+// pace is the pacer of the replay, orders received earlier than the previous one are placed right after it
+func (r *replay) pace(order *kitchen.Order) time.Duration {
+	offset := r.offset + r.interval
+
+	if received := order.ReceivedAt; received != nil {
+		if received.Time.IsZero() {
+			offset = received.Offset
+		} else {
+			if r.start.IsZero() {
+				r.start = received.Time
+			}
+			offset = received.Time.Sub(r.start)
+		}
+	}
+
+	if offset < r.offset {
+		return 0
+	}
+
+	delay := offset - r.offset
+	r.offset = offset
+
+	return time.Duration(float64(delay) / r.speed)
+}
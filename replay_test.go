@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	c "delivery/config"
+	"delivery/kitchen"
+)
+
+func TestReplay(t *testing.T) {
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	interval := ingestionInterval(c.Config)
+
+	offset := func(d time.Duration) *kitchen.ReceivedAt {
+		return &kitchen.ReceivedAt{Offset: d}
+	}
+	at := func(d time.Duration) *kitchen.ReceivedAt {
+		return &kitchen.ReceivedAt{Time: start.Add(d)}
+	}
+
+	cases := []struct {
+		name     string
+		speed    float64
+		received []*kitchen.ReceivedAt
+		want     []time.Duration
+	}{
+		{"Offset", 1, []*kitchen.ReceivedAt{offset(time.Second), offset(5 * time.Second), offset(5 * time.Second)}, []time.Duration{time.Second, 4 * time.Second, 0}},
+		{"Time", 1, []*kitchen.ReceivedAt{at(time.Minute), at(time.Minute + 3*time.Second), at(2 * time.Minute)}, []time.Duration{0, 3 * time.Second, 57 * time.Second}},
+		{"Speed", 2, []*kitchen.ReceivedAt{offset(2 * time.Second), offset(10 * time.Second)}, []time.Duration{time.Second, 4 * time.Second}},
+		{"Missing", 1, []*kitchen.ReceivedAt{offset(time.Second), nil, offset(time.Second + interval + time.Second)}, []time.Duration{time.Second, interval, time.Second}},
+		{"Earlier", 1, []*kitchen.ReceivedAt{offset(10 * time.Second), offset(5 * time.Second), offset(12 * time.Second)}, []time.Duration{10 * time.Second, 0, 2 * time.Second}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pace := newReplay(c.Config, tc.speed).pace
+
+			for i, received := range tc.received {
+				got := pace(&kitchen.Order{ID: "1", ReceivedAt: received})
+
+				if got != tc.want[i] {
+					t.Errorf("order %d: got %v want %v", i, got, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRun_Replay(t *testing.T) {
+	clock := kitchen.NewVirtualClock(time.Now())
+	k, err := createKitchenFromConfig(c.Config, clock, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orders := []*kitchen.Order{
+		{ID: "1", Temperature: "hot", ShelfLife: 300, DecayRate: 0.5, ReceivedAt: &kitchen.ReceivedAt{Offset: 10 * time.Second}},
+		{ID: "2", Temperature: "cold", ShelfLife: 300, DecayRate: 0.5, ReceivedAt: &kitchen.ReceivedAt{Offset: 10 * time.Second}},
+		{ID: "3", Temperature: "frozen", ShelfLife: 300, DecayRate: 0.5, ReceivedAt: &kitchen.ReceivedAt{Offset: 30 * time.Second}},
+	}
+
+	run(context.Background(), clock, k, orders, newReplay(c.Config, 2).pace, func() {})
+
+	cases := []struct {
+		elapsed time.Duration
+		want    int
+	}{
+		{4 * time.Second, 0},
+		{5 * time.Second, 2},
+		{14 * time.Second, 2},
+		{15 * time.Second, 3},
+	}
+
+	start := clock.Now()
+	for _, tc := range cases {
+		clock.Advance(start.Add(tc.elapsed).Sub(clock.Now()))
+
+		if got := k.Stats().Received; got != tc.want {
+			t.Errorf("after %v: got %v want %v", tc.elapsed, got, tc.want)
+		}
+	}
+}
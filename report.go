@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"delivery/kitchen"
+)
+
+// printReport writes the human readable summary of the kitchen work
+func printReport(w io.Writer, report kitchen.Report) {
+	fmt.Fprintf(w, "Received:  %d\n", report.Received)
+	fmt.Fprintf(w, "Placed:    %d\n", report.Placed)
+	fmt.Fprintf(w, "Rejected:  %d\n", report.Rejected)
+	fmt.Fprintf(w, "Delivered: %d\n", report.Delivered)
+	fmt.Fprintf(w, "Expired:   %d\n", report.Expired)
+	fmt.Fprintf(w, "Discarded: %d\n", report.Discarded)
+	fmt.Fprintf(w, "Cancelled: %d\n", report.Cancelled)
+	fmt.Fprintf(w, "Missed:    %d\n", report.Missed)
+	if report.OnShelves > 0 {
+		fmt.Fprintf(w, "Stranded:  %d\n", report.OnShelves)
+	}
+	fmt.Fprintf(w, "Average value at pickup: %.3f\n", report.AverageValue)
+	fmt.Fprintf(w, "Courier arrival: average %.1fs, max %.1fs\n", report.AverageCourierArrival, report.MaxCourierArrival)
+	fmt.Fprintf(w, "Courier wait: average %.1fs, max %.1fs\n", report.AverageCourierWait, report.MaxCourierWait)
+	fmt.Fprintf(w, "Food wait: average %.1fs, max %.1fs\n", report.AverageFoodWait, report.MaxFoodWait)
+	fmt.Fprintln(w, "Peak occupancy:")
+	for _, s := range report.Shelves {
+		fmt.Fprintf(w, "  %s: %d/%d\n", s.Name, s.PeakOccupancy, s.Capacity)
+	}
+}
+
+// writeReport saves the summary of the kitchen work as JSON
+func writeReport(path string, report kitchen.Report) error {
+	contents, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, contents, 0644)
+}
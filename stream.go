@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	c "delivery/config"
+	"delivery/kitchen"
+)
+
+// interval of the checks for new data in the followed orders file
+const followInterval = 250 * time.Millisecond
+
+// orderStream decodes orders one by one from a JSON array of orders or from a stream of JSON objects (e.g. NDJSON),
+// so the orders are never loaded into memory all at once
+type orderStream struct {
+	reader  *bufio.Reader
+	decoder *json.Decoder
+	started bool
+	array   bool
+}
+
+// newOrderStream creates the stream of orders decoded from the reader
+func newOrderStream(r io.Reader) *orderStream {
+	reader := bufio.NewReader(r)
+
+	return &orderStream{
+		reader:  reader,
+		decoder: json.NewDecoder(reader),
+	}
+}
+
+// Next returns the next order of the stream or io.EOF once the stream is over
+func (s *orderStream) Next() (*kitchen.Order, error) {
+	if !s.started {
+		s.started = true
+
+		array, err := s.isArray()
+		if err != nil {
+			return nil, err
+		}
+
+		if array {
+			if _, err := s.decoder.Token(); err != nil {
+				return nil, err
+			}
+			s.array = true
+		}
+	}
+
+	if s.array && !s.decoder.More() {
+		_, err := s.decoder.Token()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, io.EOF
+	}
+
+	var order kitchen.Order
+	if err := s.decoder.Decode(&order); err != nil {
+		return nil, err
+	}
+
+	if _, err := kitchen.NewDecayModel(order.Decay); err != nil {
+		return nil, errors.Wrapf(err, "Invalid decay of order %s", order.ID)
+	}
+
+	return &order, nil
+}
+
+// isArray checks whether the stream starts with a JSON array, the leading whitespaces are skipped
+func (s *orderStream) isArray() (bool, error) {
+	for {
+		b, err := s.reader.ReadByte()
+		if err != nil {
+			return false, err
+		}
+
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+
+		return b == '[', s.reader.UnreadByte()
+	}
+}
+
+// followReader reads the file as it grows like 'tail -f', the end of the file is reported only once the context is done
+type followReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.reader.Read(p)
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+
+		timer := time.NewTimer(followInterval)
+		select {
+		case <-r.ctx.Done():
+			timer.Stop()
+			return 0, io.EOF
+		case <-timer.C:
+		}
+	}
+}
+
+// openOrders opens the stream of orders from the file or from the stdin if the path is '-'.
+// The followed file is read until the context is done
+func openOrders(ctx context.Context, path string, follow bool) (*orderStream, io.Closer, error) {
+	if path == "-" {
+		return newOrderStream(os.Stdin), ioutil.NopCloser(os.Stdin), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if follow {
+		return newOrderStream(&followReader{ctx: ctx, reader: file}), file, nil
+	}
+
+	return newOrderStream(file), file, nil
+}
+
+// skipOrders reads up to n orders off the stream and returns the number of skipped orders.
+// The skipped orders pass the pace, so the replay goes on from the last of them
+func skipOrders(orders *orderStream, pace pacer, n int) int {
+	skipped := 0
+	for ; skipped < n; skipped++ {
+		order, err := orders.Next()
+		if err != nil {
+			break
+		}
+		pace(order)
+	}
+
+	return skipped
+}
+
+// ingest places orders on the kitchen as they arrive from the stream, no faster than the pace,
+// and calls done once the stream is over and the kitchen is empty. The ingestion stops when the context is done
+func ingest(ctx context.Context, clock kitchen.Clock, k *kitchen.Kitchen, orders *orderStream, pace pacer, done func()) {
+	interval := ingestionInterval(c.Config)
+
+	var ingested int32
+	if orders == nil {
+		ingested = 1
+	} else {
+		go func() {
+			defer atomic.StoreInt32(&ingested, 1)
+
+			read := 0
+			for {
+				order, err := orders.Next()
+				if err == io.EOF {
+					log.Infof("%d orders have been read", read)
+					return
+				}
+				if err != nil {
+					log.Error(errors.Wrap(err, "Cannot read orders"))
+					return
+				}
+				read++
+
+				// the order waits for the kitchen to continue
+				if sleep(ctx, clock, pace(order)) {
+					for k.IsOnPause() && sleep(ctx, clock, interval) {
+					}
+				}
+
+				if ctx.Err() != nil {
+					log.Warningf("Ingestion stopped, order %s is not placed", order.ID)
+					return
+				}
+
+				placeOrder(k, order)
+			}
+		}()
+	}
+
+	pollDrained(clock, k, &ingested, done)
+}
+
+// sleep waits for the duration on the clock, it returns false if the context is done earlier
+func sleep(ctx context.Context, clock kitchen.Clock, d time.Duration) bool {
+	elapsed := make(chan struct{})
+	timer := clock.AfterFunc(d, func() {
+		close(elapsed)
+	})
+
+	select {
+	case <-elapsed:
+		return true
+	case <-ctx.Done():
+		timer.Stop()
+		return false
+	}
+}
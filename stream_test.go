@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"delivery/kitchen"
+)
+
+func TestOrderStream(t *testing.T) {
+	cases := []struct {
+		name     string
+		contents string
+		want     []string
+		wantErr  bool
+	}{
+		{"Array", ` [{"id": "1", "temp": "hot"}, {"id": "2", "temp": "cold"}]`, []string{"1", "2"}, false},
+		{"EmptyArray", `[]`, nil, false},
+		{"NDJSON", "{\"id\": \"1\", \"temp\": \"hot\"}\n{\"id\": \"2\", \"temp\": \"cold\"}\n", []string{"1", "2"}, false},
+		{"Empty", "", nil, false},
+		{"Truncated_Negative", `[{"id": "1", "temp": "hot"}`, []string{"1"}, true},
+		{"Malformed_Negative", "{\"id\": \"1\", \"temp\": \"hot\"}\n{\"id\": ", []string{"1"}, true},
+		{"Decay_Negative", `{"id": "1", "temp": "hot", "decay": {"model": "cubic"}}`, nil, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := newOrderStream(strings.NewReader(tc.contents))
+
+			var got []string
+			var err error
+			for {
+				var order *kitchen.Order
+				order, err = stream.Next()
+				if err != nil {
+					break
+				}
+				got = append(got, order.ID)
+			}
+
+			if (err != io.EOF) != tc.wantErr {
+				t.Errorf("got %v want error %v", err, tc.wantErr)
+			}
+
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("got %v want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOrderStream_Follow(t *testing.T) {
+	dir, err := ioutil.TempDir("", "delivery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "orders.jsonl")
+	if err := ioutil.WriteFile(path, []byte("{\"id\": \"1\", \"temp\": \"hot\"}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, file, err := openOrders(ctx, path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if order, err := stream.Next(); err != nil || order.ID != "1" {
+		t.Fatalf("got %v, %v want %v", order, err, "1")
+	}
+
+	go func() {
+		time.Sleep(2 * followInterval)
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return
+		}
+		f.WriteString("{\"id\": \"2\", \"temp\": \"cold\"}\n")
+		f.Close()
+	}()
+
+	if order, err := stream.Next(); err != nil || order.ID != "2" {
+		t.Fatalf("got %v, %v want %v", order, err, "2")
+	}
+
+	cancel()
+
+	if _, err := stream.Next(); err != io.EOF {
+		t.Errorf("got %v want %v", err, io.EOF)
+	}
+}
+
+func TestSkipOrders(t *testing.T) {
+	stream := newOrderStream(strings.NewReader("{\"id\": \"1\"}\n{\"id\": \"2\"}\n{\"id\": \"3\"}\n"))
+
+	paced := 0
+	pace := func(*kitchen.Order) time.Duration {
+		paced++
+		return 0
+	}
+
+	if got, want := skipOrders(stream, pace, 2), 2; got != want || paced != want {
+		t.Errorf("got %v, %v want %v", got, paced, want)
+	}
+
+	if order, err := stream.Next(); err != nil || order.ID != "3" {
+		t.Errorf("got %v, %v want %v", order, err, "3")
+	}
+
+	if got, want := skipOrders(stream, pace, 2), 0; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	c "delivery/config"
+	"delivery/kitchen"
+)
+
+// sweepParameter is the config parameter the sweep runs the simulation for every value of
+type sweepParameter struct {
+	// name of the column
+	name   string
+	values []int
+	// min is the lowest valid value
+	min   int
+	apply func(config *c.DeliveryConfig, value int)
+}
+
+// sweepResult is the outcome of the simulation for one combination of the parameters
+type sweepResult struct {
+	Parameters map[string]int `json:"parameters"`
+	Received   int            `json:"received"`
+	Delivered  int            `json:"delivered"`
+	Expired    int            `json:"expired"`
+	Discarded  int            `json:"discarded"`
+	Rejected   int            `json:"rejected"`
+	Missed     int            `json:"missed"`
+	// share of the received orders that expired or were discarded
+	Waste              float64 `json:"waste"`
+	DeliveredValue     float64 `json:"deliveredValue"`
+	AverageValue       float64 `json:"averageValue"`
+	AverageCourierWait float64 `json:"averageCourierWait"`
+	MaxCourierWait     float64 `json:"maxCourierWait"`
+	AverageFoodWait    float64 `json:"averageFoodWait"`
+	MaxFoodWait        float64 `json:"maxFoodWait"`
+}
+
+// sweep runs the simulation for every combination of the parameter ranges in parallel and writes the table of the outcomes
+func sweep(args []string) error {
+	flags := flag.NewFlagSet("sweep", flag.ExitOnError)
+	ordersPath := flags.String("o", "", "Orders file path (required)")
+	configPath := flags.String("c", "config.yml", "Config file path")
+	seed := flags.Int64("seed", 0, "Random seed of every run, overrides the seed from the config")
+	replayOrders := flags.Bool("replay", false, "Place the orders at their 'receivedAt' times instead of the ingestion rate")
+	speed := flags.Float64("speed", 1, "Speed of the replay")
+	capacity := flags.String("cap", "", "Range of 'cap' of every temperature shelf, e.g. '5:20:5', '5:20' or '5,10,20'")
+	overflowDecayModifier := flags.String("overflow-decay", "", "Range of 'decayModifier' of every overflow shelf")
+	courierMin := flags.String("courier-min", "", "Range of the courier arrival 'min'")
+	courierMax := flags.String("courier-max", "", "Range of the courier arrival 'max'")
+	ingestionRate := flags.String("rate", "", "Range of the ingestion rate 'count'")
+	parallel := flags.Int("parallel", runtime.NumCPU(), "Number of simulations run at once")
+	format := flags.String("format", "csv", "Output format: 'csv' or 'json'")
+	outputPath := flags.String("out", "", "Output file path, the stdout by default")
+	flags.Parse(args)
+
+	if *ordersPath == "" {
+		flags.PrintDefaults()
+		return errors.New("Missing required arguments")
+	}
+
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("unknown format '%s'", *format)
+	}
+
+	if *parallel < 1 || *speed <= 0 {
+		return errors.New("Parallel runs and replay speed must be positive")
+	}
+
+	if err := c.Init(*configPath); err != nil {
+		return err
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			c.Config.Seed = seed
+		}
+	})
+
+	if c.Config.Seed == nil {
+		randomSeed := time.Now().UnixNano()
+		c.Config.Seed = &randomSeed
+	}
+
+	log.Infof("Random seed: %d", *c.Config.Seed)
+
+	parameters := []sweepParameter{
+		{name: "cap", min: 1, apply: func(config *c.DeliveryConfig, value int) {
+			for i := range config.Shelves {
+				config.Shelves[i].Capacity = value
+			}
+		}},
+		{name: "overflowDecayModifier", min: 0, apply: func(config *c.DeliveryConfig, value int) {
+			config.OverflowShelf.DecayModifier = value
+			for i := range config.OverflowShelves {
+				config.OverflowShelves[i].DecayModifier = value
+			}
+		}},
+		{name: "courierMin", min: 0, apply: func(config *c.DeliveryConfig, value int) {
+			config.Courier.Arrive.Min = value
+		}},
+		{name: "courierMax", min: 0, apply: func(config *c.DeliveryConfig, value int) {
+			config.Courier.Arrive.Max = value
+		}},
+		{name: "ingestionRate", min: 1, apply: func(config *c.DeliveryConfig, value int) {
+			config.Order.IngestionRate.Count = value
+		}},
+	}
+
+	var swept []sweepParameter
+	for i, value := range []string{*capacity, *overflowDecayModifier, *courierMin, *courierMax, *ingestionRate} {
+		if value == "" {
+			continue
+		}
+
+		values, err := parseRange(value)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid range of '%s'", parameters[i].name)
+		}
+
+		for _, v := range values {
+			if v < parameters[i].min {
+				return fmt.Errorf("'%s' cannot be less than %d, got %d", parameters[i].name, parameters[i].min, v)
+			}
+		}
+
+		parameters[i].values = values
+		swept = append(swept, parameters[i])
+	}
+
+	orders, err := readOrders(*ordersPath)
+	if err != nil {
+		return errors.Wrap(err, "Cannot read orders")
+	}
+
+	log.Infof("%d orders have been read", len(orders))
+
+	// the runs are too many to follow their logs
+	log.SetOutput(ioutil.Discard)
+	kitchen.SetLogOutput(ioutil.Discard)
+
+	var pace func(config *c.DeliveryConfig) pacer
+	if *replayOrders {
+		pace = func(config *c.DeliveryConfig) pacer {
+			return newReplay(config, *speed).pace
+		}
+	}
+
+	results, err := runSweep(c.Config, swept, orders, pace, *parallel)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *outputPath != "" {
+		file, err := os.Create(*outputPath)
+		if err != nil {
+			return errors.Wrap(err, "Cannot create output file")
+		}
+		defer file.Close()
+
+		w = file
+	}
+
+	if *format == "json" {
+		return writeSweepJSON(w, results)
+	}
+
+	return writeSweepCSV(w, swept, results)
+}
+
+// runSweep simulates the orders for every combination of the parameters on virtual clocks, the results are in the order of the combinations.
+// Combinations with the courier arrival 'min' greater than 'max' are skipped, the orders are placed at the ingestion rate if there is no pace
+func runSweep(base *c.DeliveryConfig, parameters []sweepParameter, orders []*kitchen.Order, pace func(config *c.DeliveryConfig) pacer, parallel int) ([]sweepResult, error) {
+	var configs []*c.DeliveryConfig
+	var combinations []map[string]int
+	for _, combination := range combine(parameters) {
+		config := copyConfig(base)
+		for i, parameter := range parameters {
+			parameter.apply(config, combination[i])
+		}
+
+		if config.Courier.Arrive.Min > config.Courier.Arrive.Max {
+			continue
+		}
+
+		values := make(map[string]int, len(parameters))
+		for i, parameter := range parameters {
+			values[parameter.name] = combination[i]
+		}
+
+		configs = append(configs, config)
+		combinations = append(combinations, values)
+	}
+
+	results := make([]sweepResult, len(configs))
+	errs := make([]error, len(configs))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for job := range jobs {
+				results[job], errs[job] = simulateConfig(configs[job], orders, pace)
+				results[job].Parameters = combinations[job]
+			}
+		}()
+	}
+
+	for job := range configs {
+		jobs <- job
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// simulateConfig simulates copies of the orders on the kitchen described by the config
+func simulateConfig(config *c.DeliveryConfig, orders []*kitchen.Order, pace func(config *c.DeliveryConfig) pacer) (sweepResult, error) {
+	copies := make([]*kitchen.Order, 0, len(orders))
+	for _, order := range orders {
+		order := *order
+		copies = append(copies, &order)
+	}
+
+	clock := kitchen.NewVirtualClock(time.Now())
+
+	k, err := createKitchenFromConfig(config, clock, *config.Seed)
+	if err != nil {
+		return sweepResult{}, err
+	}
+
+	p := fixedRate(config)
+	if pace != nil {
+		p = pace(config)
+	}
+
+	report := simulate(clock, k, copies, p, ioutil.Discard)
+
+	waste := 0.0
+	if report.Received > 0 {
+		waste = float64(report.Expired+report.Discarded) / float64(report.Received)
+	}
+
+	return sweepResult{
+		Received:           report.Received,
+		Delivered:          report.Delivered,
+		Expired:            report.Expired,
+		Discarded:          report.Discarded,
+		Rejected:           report.Rejected,
+		Missed:             report.Missed,
+		Waste:              waste,
+		DeliveredValue:     report.DeliveredValue,
+		AverageValue:       report.AverageValue,
+		AverageCourierWait: report.AverageCourierWait,
+		MaxCourierWait:     report.MaxCourierWait,
+		AverageFoodWait:    report.AverageFoodWait,
+		MaxFoodWait:        report.MaxFoodWait,
+	}, nil
+}
+
+// copyConfig returns the copy of the config that does not share the shelves
+func copyConfig(config *c.DeliveryConfig) *c.DeliveryConfig {
+	result := *config
+	result.Shelves = append(result.Shelves[:0:0], config.Shelves...)
+	result.OverflowShelves = append(result.OverflowShelves[:0:0], config.OverflowShelves...)
+
+	return &result
+}
+
+// combine returns every combination of the values of the parameters, the last parameter changes first
+func combine(parameters []sweepParameter) [][]int {
+	combinations := [][]int{{}}
+	for _, parameter := range parameters {
+		var next [][]int
+		for _, combination := range combinations {
+			for _, value := range parameter.values {
+				next = append(next, append(combination[:len(combination):len(combination)], value))
+			}
+		}
+		combinations = next
+	}
+
+	return combinations
+}
+
+// parseRange parses the single value, the comma separated values or the range 'from:to' or 'from:to:step'
+func parseRange(value string) ([]int, error) {
+	if strings.Contains(value, ",") {
+		var values []int
+		for _, part := range strings.Split(value, ",") {
+			v, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+
+		return values, nil
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("want 'from:to:step', got '%s'", value)
+	}
+
+	bounds := []int{0, 0, 1}
+	for i, part := range parts {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		bounds[i] = v
+	}
+
+	from, to, step := bounds[0], bounds[1], bounds[2]
+	if len(parts) == 1 {
+		to = from
+	}
+
+	if step <= 0 || to < from {
+		return nil, fmt.Errorf("want 'from' not greater than 'to' and a positive 'step', got '%s'", value)
+	}
+
+	var values []int
+	for v := from; v <= to; v += step {
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+// writeSweepCSV writes the results as CSV with a column for every swept parameter
+func writeSweepCSV(w io.Writer, parameters []sweepParameter, results []sweepResult) error {
+	writer := csv.NewWriter(w)
+
+	var header []string
+	for _, parameter := range parameters {
+		header = append(header, parameter.name)
+	}
+	header = append(header, "received", "delivered", "expired", "discarded", "rejected", "missed", "waste",
+		"deliveredValue", "averageValue", "averageCourierWait", "maxCourierWait", "averageFoodWait", "maxFoodWait")
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		var record []string
+		for _, parameter := range parameters {
+			record = append(record, strconv.Itoa(result.Parameters[parameter.name]))
+		}
+
+		for _, v := range []int{result.Received, result.Delivered, result.Expired, result.Discarded, result.Rejected, result.Missed} {
+			record = append(record, strconv.Itoa(v))
+		}
+
+		for _, v := range []float64{result.Waste, result.DeliveredValue, result.AverageValue, result.AverageCourierWait,
+			result.MaxCourierWait, result.AverageFoodWait, result.MaxFoodWait} {
+			record = append(record, strconv.FormatFloat(v, 'f', 3, 64))
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// writeSweepJSON writes the results as a JSON array
+func writeSweepJSON(w io.Writer, results []sweepResult) error {
+	contents, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", contents)
+	return err
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	c "delivery/config"
+)
+
+func TestParseRange(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    []int
+		wantErr bool
+	}{
+		{"5", []int{5}, false},
+		{"5:8", []int{5, 6, 7, 8}, false},
+		{"5:20:5", []int{5, 10, 15, 20}, false},
+		{"1, 2,5", []int{1, 2, 5}, false},
+		{"8:5", nil, true},
+		{"5:8:0", nil, true},
+		{"1:2:3:4", nil, true},
+		{"a", nil, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := parseRange(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got %v want error %v", err, tc.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunSweep(t *testing.T) {
+	orders, err := readOrders("orders_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seed := int64(1)
+	base := copyConfig(c.Config)
+	base.Seed = &seed
+
+	parameters := []sweepParameter{
+		{name: "cap", values: []int{2, 10}, apply: func(config *c.DeliveryConfig, value int) {
+			for i := range config.Shelves {
+				config.Shelves[i].Capacity = value
+			}
+		}},
+		{name: "courierMin", values: []int{2, 50}, apply: func(config *c.DeliveryConfig, value int) {
+			config.Courier.Arrive.Min = value
+		}},
+	}
+
+	results, err := runSweep(base, parameters, orders, nil, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Combinations", func(t *testing.T) {
+		// courier 'min' 50 is greater than 'max' of the config
+		want := []map[string]int{{"cap": 2, "courierMin": 2}, {"cap": 10, "courierMin": 2}}
+
+		if len(results) != len(want) {
+			t.Fatalf("got %v want %v", len(results), len(want))
+		}
+
+		for i := range want {
+			if !reflect.DeepEqual(results[i].Parameters, want[i]) {
+				t.Errorf("got %v want %v", results[i].Parameters, want[i])
+			}
+
+			if results[i].Received != len(orders) {
+				t.Errorf("got %v want %v", results[i].Received, len(orders))
+			}
+		}
+
+		if results[0].Waste < results[1].Waste {
+			t.Errorf("got %v want %v", results[0].Waste, "more waste on the smaller shelves")
+		}
+	})
+
+	t.Run("Parallel", func(t *testing.T) {
+		sequential, err := runSweep(base, parameters, orders, nil, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(results, sequential) {
+			t.Errorf("got %+v want %+v", results, sequential)
+		}
+	})
+
+	t.Run("Base", func(t *testing.T) {
+		if base.Shelves[0].Capacity != c.Config.Shelves[0].Capacity {
+			t.Errorf("got %v want %v", base.Shelves[0].Capacity, c.Config.Shelves[0].Capacity)
+		}
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeSweepCSV(&buf, parameters, results); err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 3 || !strings.HasPrefix(lines[0], "cap,courierMin,received,") || !strings.HasPrefix(lines[1], "2,2,") {
+			t.Errorf("got %v want %v", lines, "header and 2 rows")
+		}
+	})
+}